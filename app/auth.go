@@ -1,30 +1,66 @@
 package app
 
 import (
+	"fmt"
+	"freedom-sentry/config"
+	"freedom-sentry/http"
 	"freedom-sentry/mediawiki"
 	"freedom-sentry/mediawiki/action/query"
+	"freedom-sentry/mediawiki/auth"
 	"log"
+	"net/http/cookiejar"
+	"os"
 )
 
-func acquireCsrfTokenFn(api mediawiki.Api) (mediawiki.Token, error) {
+// createAuthenticator returns the configured authenticator with the HTTP client to send its requests. It panics
+// if the authentication method is unknown.
+func createAuthenticator(apiEndpoint string) (mediawiki.Authenticator, http.Client) {
+	switch method := config.GetAuthMethod(); method {
+	case config.AuthMethodOAuth2:
+		return auth.NewBearer(os.Getenv(config.EnvAccessToken)), http.DefaultClient
+	case config.AuthMethodOAuth1:
+		return auth.NewOAuth1(
+			os.Getenv(config.EnvClientKey),
+			os.Getenv(config.EnvClientSecret),
+			os.Getenv(config.EnvAccessToken),
+			os.Getenv(config.EnvAccessSecret),
+		), http.DefaultClient
+	case config.AuthMethodBotPassword:
+		jar, err := cookiejar.New(nil)
+		if err != nil {
+			panic(err)
+		}
+
+		client := http.NewSessionClient(jar)
+
+		return auth.NewBotPassword(apiEndpoint, client, os.Getenv(config.EnvLoginName), os.Getenv(config.EnvLoginPassword)), client
+	default:
+		panic(fmt.Errorf("unknown authentication method %q, expected %s, %s or %s", method,
+			config.AuthMethodOAuth2, config.AuthMethodOAuth1, config.AuthMethodBotPassword))
+	}
+}
+
+// acquireTokenFn requests a token of the type, which is cached by the API until it is rejected.
+func acquireTokenFn(api mediawiki.Api, tokenType mediawiki.TokenType) (mediawiki.Token, error) {
 	tokensQm := &query.TokensMetaQuery{
-		Type: []string{"csrf"},
+		Type: []string{string(tokenType)},
 	}
 	a := query.Query{
 		Meta: []query.Meta{tokensQm},
 	}
 
-	log.Println("requesting a new CSRF token")
+	log.Printf("requesting a new %s token", tokenType)
 
 	err := api.Execute(a)
 	if err != nil {
-		log.Println("failed to retrieve a CSRF token:", err)
+		log.Printf("failed to retrieve a %s token: %v", tokenType, err)
 		return "", err
 	}
 
-	csrfToken := tokensQm.GetTokens().Csrf
-
-	log.Println("acquired a new CSRF token:", csrfToken)
+	token := tokensQm.GetTokens().Get(tokenType)
+	if token == "" {
+		return "", fmt.Errorf("no %s token in the response", tokenType)
+	}
 
-	return mediawiki.Token(csrfToken), nil
+	return mediawiki.Token(token), nil
 }
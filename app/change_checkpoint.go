@@ -0,0 +1,105 @@
+package app
+
+import (
+	"encoding/json"
+	"errors"
+	"freedom-sentry/mediawiki"
+	"freedom-sentry/util"
+	"golang.org/x/exp/maps"
+	"io/fs"
+	"log"
+	"os"
+	"time"
+)
+
+const defaultLookback = 30 * time.Minute
+
+// changeCheckpoint is the last recent change processed by the scanner, along with the changes processed
+// within the overlap of the next poll.
+type changeCheckpoint struct {
+	RcId      mediawiki.RecentChangeId               `json:"rcid"`
+	Timestamp time.Time                              `json:"timestamp"`
+	Seen      map[mediawiki.RecentChangeId]time.Time `json:"seen,omitempty"`
+}
+
+// advance returns the checkpoint after the given changes are processed.
+func (c changeCheckpoint) advance(changes []mediawiki.Revision) changeCheckpoint {
+	next := changeCheckpoint{
+		RcId:      c.RcId,
+		Timestamp: c.Timestamp,
+		Seen:      make(map[mediawiki.RecentChangeId]time.Time, len(c.Seen)+len(changes)),
+	}
+
+	maps.Copy(next.Seen, c.Seen)
+
+	for _, change := range changes {
+		next.Seen[change.RcId] = change.Timestamp
+
+		if change.Timestamp.After(next.Timestamp) || (change.Timestamp.Equal(next.Timestamp) && change.RcId > next.RcId) {
+			next.RcId = change.RcId
+			next.Timestamp = change.Timestamp
+		}
+	}
+
+	maps.DeleteFunc(next.Seen, func(_ mediawiki.RecentChangeId, timestamp time.Time) bool {
+		return timestamp.Before(next.Timestamp.Add(-changeOverlap))
+	})
+
+	return next
+}
+
+func (c changeCheckpoint) equal(other changeCheckpoint) bool {
+	return c.RcId == other.RcId && c.Timestamp.Equal(other.Timestamp) && maps.EqualFunc(c.Seen, other.Seen, time.Time.Equal)
+}
+
+func loadCheckpoint(path string) (changeCheckpoint, bool, error) {
+	var checkpoint changeCheckpoint
+
+	content, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return checkpoint, false, nil
+	}
+	if err != nil {
+		return checkpoint, false, err
+	}
+
+	err = json.Unmarshal(content, &checkpoint)
+	if err != nil {
+		return checkpoint, false, err
+	}
+
+	return checkpoint, true, nil
+}
+
+func saveCheckpoint(path string, checkpoint changeCheckpoint) error {
+	content, err := json.Marshal(checkpoint)
+	if err != nil {
+		return err
+	}
+
+	return util.WriteFileAtomically(path, content)
+}
+
+// initialCheckpoint picks the position the scanner starts from: since, unless zero, the checkpoint saved at path
+// or the default lookback, in this order. The saved position is never older than the lookback ceiling.
+func initialCheckpoint(now time.Time, path string, since time.Time, maxLookback time.Duration) changeCheckpoint {
+	if !since.IsZero() {
+		return changeCheckpoint{Timestamp: since}
+	}
+
+	checkpoint, ok, err := loadCheckpoint(path)
+	if err != nil {
+		log.Println("failed to load the recent changes checkpoint:", err)
+	}
+	if !ok {
+		return changeCheckpoint{Timestamp: now.Add(-defaultLookback)}
+	}
+
+	ceiling := now.Add(-maxLookback)
+	if checkpoint.Timestamp.Before(ceiling) {
+		log.Println("the recent changes checkpoint at", checkpoint.Timestamp, "is too old, scanning since", ceiling)
+		return changeCheckpoint{Timestamp: ceiling}
+	}
+
+	return checkpoint
+}
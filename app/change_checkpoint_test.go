@@ -0,0 +1,169 @@
+package app
+
+import (
+	"freedom-sentry/mediawiki"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+var checkpointStart = time.Date(2022, 4, 20, 12, 13, 14, 0, time.UTC)
+
+func change(rcId mediawiki.RecentChangeId, offset time.Duration) mediawiki.Revision {
+	return mediawiki.Revision{RcId: rcId, Timestamp: checkpointStart.Add(offset)}
+}
+
+func Test_changeCheckpoint_advance(t *testing.T) {
+	tests := []struct {
+		name    string
+		last    changeCheckpoint
+		changes []mediawiki.Revision
+		want    changeCheckpoint
+	}{
+		{
+			name: "No changes",
+			last: changeCheckpoint{RcId: 1, Timestamp: checkpointStart, Seen: map[mediawiki.RecentChangeId]time.Time{1: checkpointStart}},
+			want: changeCheckpoint{RcId: 1, Timestamp: checkpointStart, Seen: map[mediawiki.RecentChangeId]time.Time{1: checkpointStart}},
+		},
+		{
+			name:    "Advances to the latest change",
+			last:    changeCheckpoint{Timestamp: checkpointStart},
+			changes: []mediawiki.Revision{change(1, time.Second), change(2, 2*time.Second)},
+			want: changeCheckpoint{RcId: 2, Timestamp: checkpointStart.Add(2 * time.Second), Seen: map[mediawiki.RecentChangeId]time.Time{
+				1: checkpointStart.Add(time.Second),
+				2: checkpointStart.Add(2 * time.Second),
+			}},
+		},
+		{
+			name:    "Changes of the same second are ordered by rcid",
+			last:    changeCheckpoint{RcId: 5, Timestamp: checkpointStart, Seen: map[mediawiki.RecentChangeId]time.Time{5: checkpointStart}},
+			changes: []mediawiki.Revision{change(7, 0), change(6, 0)},
+			want: changeCheckpoint{RcId: 7, Timestamp: checkpointStart, Seen: map[mediawiki.RecentChangeId]time.Time{
+				5: checkpointStart,
+				6: checkpointStart,
+				7: checkpointStart,
+			}},
+		},
+		{
+			name:    "Changes of the overlap do not move the checkpoint back",
+			last:    changeCheckpoint{RcId: 5, Timestamp: checkpointStart, Seen: map[mediawiki.RecentChangeId]time.Time{5: checkpointStart}},
+			changes: []mediawiki.Revision{change(4, -30*time.Second), change(5, 0)},
+			want: changeCheckpoint{RcId: 5, Timestamp: checkpointStart, Seen: map[mediawiki.RecentChangeId]time.Time{
+				4: checkpointStart.Add(-30 * time.Second),
+				5: checkpointStart,
+			}},
+		},
+		{
+			name: "Changes older than the overlap are pruned",
+			last: changeCheckpoint{RcId: 5, Timestamp: checkpointStart, Seen: map[mediawiki.RecentChangeId]time.Time{
+				4: checkpointStart.Add(-30 * time.Second),
+				5: checkpointStart,
+			}},
+			changes: []mediawiki.Revision{change(6, 45*time.Second)},
+			want: changeCheckpoint{RcId: 6, Timestamp: checkpointStart.Add(45 * time.Second), Seen: map[mediawiki.RecentChangeId]time.Time{
+				5: checkpointStart,
+				6: checkpointStart.Add(45 * time.Second),
+			}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			seen := len(tt.last.Seen)
+
+			got := tt.last.advance(tt.changes)
+			if !got.equal(tt.want) {
+				t.Errorf("advance() = %+v, want %+v", got, tt.want)
+			}
+
+			if len(tt.last.Seen) != seen {
+				t.Errorf("advance() must not change the checkpoint it is called on")
+			}
+		})
+	}
+}
+
+func Test_saveCheckpoint(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "checkpoint.json")
+
+	if _, ok, err := loadCheckpoint(path); ok || err != nil {
+		t.Errorf("loadCheckpoint() = %v, %v, want nothing saved", ok, err)
+	}
+
+	saved := changeCheckpoint{RcId: 42, Timestamp: checkpointStart, Seen: map[mediawiki.RecentChangeId]time.Time{42: checkpointStart}}
+	if err := saveCheckpoint(path, saved); err != nil {
+		t.Fatalf("saveCheckpoint() error = %v", err)
+	}
+
+	if got, ok, err := loadCheckpoint(path); !ok || err != nil || !got.equal(saved) {
+		t.Errorf("loadCheckpoint() = %+v, %v, %v, want %+v", got, ok, err, saved)
+	}
+}
+
+func Test_initialCheckpoint(t *testing.T) {
+	now := checkpointStart
+	maxLookback := 24 * time.Hour
+	saved := changeCheckpoint{RcId: 42, Timestamp: now.Add(-time.Hour), Seen: map[mediawiki.RecentChangeId]time.Time{42: now.Add(-time.Hour)}}
+	outdated := changeCheckpoint{RcId: 42, Timestamp: now.Add(-48 * time.Hour)}
+
+	tests := []struct {
+		name  string
+		saved *changeCheckpoint
+		file  string
+		since time.Time
+		want  changeCheckpoint
+	}{
+		{
+			name: "Nothing saved",
+			want: changeCheckpoint{Timestamp: now.Add(-defaultLookback)},
+		},
+		{
+			name:  "Saved checkpoint",
+			saved: &saved,
+			want:  saved,
+		},
+		{
+			name:  "Saved checkpoint is capped by the lookback",
+			saved: &outdated,
+			want:  changeCheckpoint{Timestamp: now.Add(-maxLookback)},
+		},
+		{
+			name: "Invalid file",
+			file: "{invalid json}",
+			want: changeCheckpoint{Timestamp: now.Add(-defaultLookback)},
+		},
+		{
+			name:  "Since overrides the saved checkpoint",
+			saved: &saved,
+			since: now.Add(-5 * time.Minute),
+			want:  changeCheckpoint{Timestamp: now.Add(-5 * time.Minute)},
+		},
+		{
+			name:  "Since is not capped by the lookback",
+			since: now.Add(-72 * time.Hour),
+			want:  changeCheckpoint{Timestamp: now.Add(-72 * time.Hour)},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "checkpoint.json")
+
+			if tt.saved != nil {
+				if err := saveCheckpoint(path, *tt.saved); err != nil {
+					t.Fatalf("saveCheckpoint() error = %v", err)
+				}
+			}
+			if tt.file != "" {
+				if err := os.WriteFile(path, []byte(tt.file), 0600); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			if got := initialCheckpoint(now, path, tt.since, maxLookback); !got.equal(tt.want) {
+				t.Errorf("initialCheckpoint() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
@@ -1,81 +1,263 @@
 package app
 
 import (
-	"freedom-sentry/config"
 	"freedom-sentry/mediawiki"
 	"freedom-sentry/suppressor"
+	"golang.org/x/exp/slices"
 	"log"
 )
 
-type changeHandlerFunc func([]mediawiki.Revision) error
+// changeHandlerFunc handles fresh changes, returning the handles of the revisions it submitted for suppression.
+type changeHandlerFunc func([]mediawiki.Revision) ([]*suppressor.Handle, error)
+
+// changesHandlerFunc handles fresh changes completely, the scanner moves past them only if it succeeds.
+type changesHandlerFunc func([]mediawiki.Revision) error
+
+// createChangesHandler passes the changes to every subhandler and waits for the submitted revisions to have an
+// outcome, by then those failing are kept by the dead letter queue. It fails if any of the subhandlers did.
+func createChangesHandler(subhandlers []changeHandlerFunc) changesHandlerFunc {
+	return func(changes []mediawiki.Revision) error {
+		var handles []*suppressor.Handle
+		var firstErr error
 
-func createChangesHandler(subhandlers []changeHandlerFunc, changeProcessor <-chan []mediawiki.Revision) func() {
-	handler := func(changes []mediawiki.Revision) {
 		for _, subhandler := range subhandlers {
-			_ = subhandler(changes)
+			submitted, err := subhandler(changes)
+			if err != nil && firstErr == nil {
+				firstErr = err
+			}
+
+			handles = append(handles, submitted...)
 		}
+
+		for _, handle := range handles {
+			handle.Wait()
+		}
+
+		return firstErr
 	}
+}
 
-	return func() {
-		for {
-			select {
-			case changes := <-changeProcessor:
-				handler(changes)
-			}
+// suppressionIndex looks up active entries of the list for titles and revisions.
+type suppressionIndex struct {
+	byTitle  map[string]suppressor.ListEntry
+	byHash   map[string]suppressor.ListEntry
+	byUser   map[string]suppressor.ListEntry
+	patterns []suppressor.ListEntry
+
+	titles *suppressor.TitleIndex
+}
+
+// newSuppressionIndex indexes the active entries of the list, those of pages by their current titles.
+func newSuppressionIndex(entries []suppressor.ListEntry, titles *suppressor.TitleIndex) suppressionIndex {
+	index := suppressionIndex{
+		byTitle: make(map[string]suppressor.ListEntry),
+		byHash:  make(map[string]suppressor.ListEntry),
+		byUser:  make(map[string]suppressor.ListEntry),
+		titles:  titles,
+	}
+
+	for _, entry := range entries {
+		switch {
+		case entry.Title != "":
+			index.byTitle[entry.Title] = entry
+		case entry.Hash != "":
+			index.byHash[entry.Hash] = entry
+		case entry.Pattern != nil:
+			index.patterns = append(index.patterns, entry)
+		default:
+			index.byUser[entry.User] = entry
 		}
 	}
+
+	return index
 }
 
-func createHandlerChangeForSuppressor(pageRepo suppressor.SuppressedPageRepository, revSuppressor suppressor.RevisionSuppressor) changeHandlerFunc {
-	return func(changes []mediawiki.Revision) error {
-		list, err := pageRepo.GetAll()
+// forTitle returns the entry of the page, either listed by its title or its hash, or matched by a pattern.
+func (i suppressionIndex) forTitle(title string) (suppressor.ListEntry, bool) {
+	if entry, ok := i.byTitle[title]; ok {
+		return entry, true
+	}
+
+	if len(i.byHash) > 0 && i.titles.IsEnabled() {
+		if entry, ok := i.byHash[i.titles.Hash(title)]; ok {
+			entry.Title = title
+			return entry, true
+		}
+	}
+
+	for _, entry := range i.patterns {
+		if entry.Pattern.MatchString(title) {
+			entry.Title = title
+			return entry, true
+		}
+	}
+
+	return suppressor.ListEntry{}, false
+}
+
+// forRevision returns the entry of the page or the author covering the revision.
+func (i suppressionIndex) forRevision(rev mediawiki.Revision) (suppressor.ListEntry, bool) {
+	if entry, ok := i.forTitle(rev.Title); ok && entry.Covers(rev) {
+		return entry, true
+	}
+
+	if entry, ok := i.byUser[rev.User]; ok && rev.User != "" && entry.Covers(rev) {
+		return entry, true
+	}
+
+	return suppressor.ListEntry{}, false
+}
+
+// isUnindexedHash reports whether the entry was found by the hash of a title missing from the title index.
+func (i suppressionIndex) isUnindexedHash(entry suppressor.ListEntry) bool {
+	_, isIndexed := i.byTitle[entry.Title]
+
+	return entry.Hash != "" && !isIndexed
+}
+
+// createHideProfileFn looks up the details to hide in the entries of the list.
+func createHideProfileFn(lists *listIndexCache) suppressor.HideProfileFn {
+	return func(title string) mediawiki.Visibility {
+		indexedList, err := lists.get()
 		if err != nil {
-			log.Println("failed to get suppression list:", err)
-			return err
+			return suppressor.DefaultHideProfile
+		}
+
+		entry, ok := indexedList.forTitle(title)
+		if !ok {
+			return suppressor.DefaultHideProfile
 		}
 
-		indexedList := make(map[string]bool, len(list))
-		for _, title := range list {
-			indexedList[title] = true
+		return entry.Hide
+	}
+}
+
+func createHandlerChangeForSuppressor(lists *listIndexCache, revSuppressor suppressor.RevisionSuppressor) changeHandlerFunc {
+	return func(changes []mediawiki.Revision) ([]*suppressor.Handle, error) {
+		indexedList, err := lists.get()
+		if err != nil {
+			return nil, err
 		}
 
 		revs := make([]mediawiki.Revision, 0, len(changes))
 		for _, rev := range changes {
-			if _, inList := indexedList[rev.Title]; !inList {
+			if rev.Log != nil {
+				continue
+			}
+
+			entry, inList := indexedList.forRevision(rev)
+			if !inList {
 				continue
 			}
 
+			if indexedList.isUnindexedHash(entry) {
+				lists.indexHashedTitle(entry.Title)
+			}
+
 			revs = append(revs, rev)
 		}
 
-		err = revSuppressor.SuppressRevisions(revs)
+		handle := revSuppressor.SuppressRevisions(revs)
+		handle.OnComplete(logFailedOutcomes)
+
+		return []*suppressor.Handle{handle}, nil
+	}
+}
+
+// createHandlerForLogEvents suppresses the whole history of listed pages that gained revisions through a move,
+// an import, a history merge or an undeletion. Moves of listed pages are tracked to match their new titles later.
+// It fails if the revisions of any of the pages could not be submitted.
+func createHandlerForLogEvents(lists *listIndexCache, pageSuppressor suppressor.PageSuppressor) changeHandlerFunc {
+	return func(changes []mediawiki.Revision) ([]*suppressor.Handle, error) {
+		indexedList, err := lists.get()
 		if err != nil {
-			log.Println("failed to suppress revisions", revs)
-			return err
+			return nil, err
+		}
+
+		var handles []*suppressor.Handle
+		var firstErr error
+
+		affected := make(map[string]bool)
+		for _, change := range changes {
+			if change.Log == nil || !change.Log.BringsRevisions() {
+				continue
+			}
+
+			historyTitle := change.Log.HistoryTitle(change.Title)
+
+			entry, isListed := indexedList.forTitle(change.Title)
+			if !isListed {
+				entry, isListed = indexedList.forTitle(historyTitle)
+			}
+			if !isListed {
+				continue
+			}
+
+			if indexedList.isUnindexedHash(entry) {
+				lists.indexHashedTitle(entry.Title)
+			}
+
+			if _, isSourceListed := indexedList.byTitle[change.Title]; isSourceListed && change.Log.Type == mediawiki.LogTypeMove {
+				log.Printf("listed page [%s] was moved to [%s]", change.Title, historyTitle)
+
+				err := lists.recordMove(change.Title, historyTitle, change.Timestamp)
+				if err != nil {
+					log.Println("failed to record the move of", change.Title, "error:", err)
+				}
+			}
+
+			if affected[historyTitle] {
+				continue
+			}
+			affected[historyTitle] = true
+
+			log.Printf("%s/%s log entry affects [%s]", change.Log.Type, change.Log.Action, historyTitle)
+
+			entry.Title = historyTitle
+
+			handle, err := pageSuppressor.SuppressEntry(entry)
+			if err != nil {
+				if firstErr == nil {
+					firstErr = err
+				}
+				continue
+			}
+
+			handle.OnComplete(logFailedOutcomes)
+			handles = append(handles, handle)
 		}
 
-		return nil
+		return handles, firstErr
 	}
 }
 
-func createHandlerForListUpdate(listUpdatedChan chan bool) changeHandlerFunc {
-	var lastSeenListRev mediawiki.RevisionId
+func logFailedOutcomes(outcomes []suppressor.Outcome) {
+	for _, outcome := range outcomes {
+		if outcome.Err != nil {
+			log.Printf("failed to suppress revision %s of [%s]: %v", outcome.Revision.Id, outcome.Revision.Title, outcome.Err)
+		}
+	}
+}
 
-	return func(changes []mediawiki.Revision) error {
+// createHandlerForListUpdate signals new revisions of the wiki pages holding the list.
+func createHandlerForListUpdate(listNames []string, listUpdatedChan chan bool) changeHandlerFunc {
+	lastSeenListRevs := make(map[string]mediawiki.RevisionId, len(listNames))
+
+	return func(changes []mediawiki.Revision) ([]*suppressor.Handle, error) {
 		for _, rev := range changes {
-			if rev.Title != config.GetSuppressionListName() {
+			if rev.Log != nil || !slices.Contains(listNames, rev.Title) {
 				continue
 			}
 
-			if rev.Id == lastSeenListRev {
+			if rev.Id == lastSeenListRevs[rev.Title] {
 				continue
 			}
 
-			lastSeenListRev = rev.Id
+			lastSeenListRevs[rev.Title] = rev.Id
 
 			listUpdatedChan <- true
 		}
 
-		return nil
+		return nil, nil
 	}
 }
@@ -0,0 +1,167 @@
+package app
+
+import (
+	"errors"
+	"freedom-sentry/mediawiki"
+	"freedom-sentry/suppressor"
+	"golang.org/x/exp/slices"
+	"regexp"
+	"testing"
+)
+
+type mockPageRepository struct {
+	entries []suppressor.ListEntry
+	version suppressor.ListVersion
+}
+
+func (m *mockPageRepository) GetAll() ([]suppressor.ListEntry, suppressor.ListVersion, error) {
+	return m.entries, m.version, nil
+}
+
+func (m *mockPageRepository) HasChanged() (bool, error) {
+	return false, nil
+}
+
+// mockPageSuppressor records the entries and fails to submit their revisions, which are of no interest to the
+// handlers
+type mockPageSuppressor struct {
+	titles []string
+}
+
+func (m *mockPageSuppressor) SuppressEntry(entry suppressor.ListEntry) (*suppressor.Handle, error) {
+	m.titles = append(m.titles, entry.Title)
+
+	return nil, errors.New("revisions are not suppressed in tests")
+}
+
+func (m *mockPageSuppressor) SuppressEntries([]suppressor.ListEntry) error {
+	return nil
+}
+
+func logChange(title, logType, action, target string) mediawiki.Revision {
+	return mediawiki.Revision{
+		Title:     title,
+		Timestamp: checkpointStart,
+		Log:       &mediawiki.LogEvent{Type: logType, Action: action, TargetTitle: target},
+	}
+}
+
+func Test_createHandlerForLogEvents(t *testing.T) {
+	listed := []suppressor.ListEntry{
+		{Title: "Listed"},
+		{Pattern: regexp.MustCompile(`^Secret/`)},
+	}
+
+	tests := []struct {
+		name           string
+		changes        []mediawiki.Revision
+		wantSuppressed []string
+		wantMoves      []suppressor.Move
+	}{
+		{
+			name:           "Move of a listed page",
+			changes:        []mediawiki.Revision{logChange("Listed", mediawiki.LogTypeMove, "move", "Renamed")},
+			wantSuppressed: []string{"Renamed"},
+			wantMoves:      []suppressor.Move{{From: "Listed", To: "Renamed", MovedAt: checkpointStart}},
+		},
+		{
+			name:           "Move to a listed title",
+			changes:        []mediawiki.Revision{logChange("Other", mediawiki.LogTypeMove, "move", "Listed")},
+			wantSuppressed: []string{"Listed"},
+		},
+		{
+			name:           "Move of a page matched by a pattern is not tracked",
+			changes:        []mediawiki.Revision{logChange("Secret/A", mediawiki.LogTypeMove, "move", "Public/A")},
+			wantSuppressed: []string{"Public/A"},
+		},
+		{
+			name:           "History merged into a listed page",
+			changes:        []mediawiki.Revision{logChange("Other", mediawiki.LogTypeMerge, "merge", "Listed")},
+			wantSuppressed: []string{"Listed"},
+		},
+		{
+			name:           "Import to a listed page",
+			changes:        []mediawiki.Revision{logChange("Listed", mediawiki.LogTypeImport, "upload", "")},
+			wantSuppressed: []string{"Listed"},
+		},
+		{
+			name:           "Undeletion of a listed page",
+			changes:        []mediawiki.Revision{logChange("Listed", mediawiki.LogTypeDelete, mediawiki.LogActionRestore, "")},
+			wantSuppressed: []string{"Listed"},
+		},
+		{
+			name: "Log entries bringing no revisions are skipped",
+			changes: []mediawiki.Revision{
+				logChange("Listed", mediawiki.LogTypeDelete, "delete", ""),
+				logChange("Listed", "protect", "protect", ""),
+				{Title: "Listed", Id: "42"},
+			},
+		},
+		{
+			name:    "Pages not listed are skipped",
+			changes: []mediawiki.Revision{logChange("Other", mediawiki.LogTypeImport, "upload", "")},
+		},
+		{
+			name: "Affected pages are suppressed once",
+			changes: []mediawiki.Revision{
+				logChange("Listed", mediawiki.LogTypeImport, "upload", ""),
+				logChange("Other", mediawiki.LogTypeMerge, "merge", "Listed"),
+				logChange("Listed", mediawiki.LogTypeDelete, mediawiki.LogActionRestore, ""),
+			},
+			wantSuppressed: []string{"Listed"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pageRepo := &mockPageRepository{entries: listed}
+			moves := suppressor.NewMoveTracker("")
+			pageSuppressor := &mockPageSuppressor{}
+
+			lists := newListIndexCache(pageRepo, moves, suppressor.NewTitleIndex("", ""))
+			handler := createHandlerForLogEvents(lists, pageSuppressor)
+
+			// Every page the handler suppresses fails
+			if _, err := handler(tt.changes); (err != nil) != (len(tt.wantSuppressed) > 0) {
+				t.Errorf("handler error = %v, want an error for failed pages only", err)
+			}
+
+			if !slices.Equal(pageSuppressor.titles, tt.wantSuppressed) {
+				t.Errorf("suppressed %v, want %v", pageSuppressor.titles, tt.wantSuppressed)
+			}
+
+			gotMoves, _ := moves.GetAll()
+			if !slices.EqualFunc(gotMoves, tt.wantMoves, func(a, b suppressor.Move) bool {
+				return a.From == b.From && a.To == b.To && a.MovedAt.Equal(b.MovedAt)
+			}) {
+				t.Errorf("recorded moves %v, want %v", gotMoves, tt.wantMoves)
+			}
+		})
+	}
+}
+
+func Test_createChangesHandler(t *testing.T) {
+	var calls int
+	succeeding := func([]mediawiki.Revision) ([]*suppressor.Handle, error) {
+		calls++
+		return nil, nil
+	}
+	failing := func([]mediawiki.Revision) ([]*suppressor.Handle, error) {
+		calls++
+		return nil, errors.New("list unavailable")
+	}
+
+	if err := createChangesHandler([]changeHandlerFunc{succeeding, succeeding})(nil); err != nil {
+		t.Errorf("handler error = %v, want nil", err)
+	}
+
+	calls = 0
+
+	if err := createChangesHandler([]changeHandlerFunc{failing, succeeding})(nil); err == nil {
+		t.Errorf("handler must fail if a subhandler fails")
+	}
+
+	if calls != 2 {
+		t.Errorf("called %d subhandlers, want every one despite the failure", calls)
+	}
+}
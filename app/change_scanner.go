@@ -7,26 +7,37 @@ import (
 	"time"
 )
 
-func scanChanges(repo suppressor.RevisionRepository, since time.Time, changeProcessor chan<- []mediawiki.Revision) (time.Time, error) {
+// changeOverlap is how far back every poll reaches behind the checkpoint. Changes of the same second, or those
+// committed late with an older timestamp, are picked up by the next poll and deduplicated by their rcid.
+const changeOverlap = time.Minute
+
+// scanChanges passes the changes not seen yet to the handler and returns the checkpoint past them. The checkpoint
+// is kept if the handler fails, so that the next poll returns the changes again.
+func scanChanges(repo suppressor.RevisionRepository, last changeCheckpoint, handleChanges changesHandlerFunc) (changeCheckpoint, error) {
+	since := last.Timestamp.Add(-changeOverlap)
+
 	changes, err := repo.GetRecentChanges(since)
 	if err != nil {
 		log.Println("failed to get recent changes since", since, "error:", err)
-		return time.Time{}, err
+		return last, err
 	}
 
-	mostRecent := since
+	next := last.advance(changes)
 
-	if len(changes) == 0 {
-		return mostRecent, nil
+	fresh := make([]mediawiki.Revision, 0, len(changes))
+	for _, change := range changes {
+		if _, isSeen := last.Seen[change.RcId]; !isSeen {
+			fresh = append(fresh, change)
+		}
 	}
 
-	changeProcessor <- changes
-
-	for _, change := range changes {
-		if change.Timestamp.After(mostRecent) {
-			mostRecent = change.Timestamp.Add(time.Second)
+	if len(fresh) > 0 {
+		err = handleChanges(fresh)
+		if err != nil {
+			log.Println("failed to handle recent changes, retrying them with the next poll:", err)
+			return last, err
 		}
 	}
 
-	return mostRecent, nil
+	return next, nil
 }
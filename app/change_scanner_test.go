@@ -0,0 +1,149 @@
+package app
+
+import (
+	"errors"
+	"freedom-sentry/mediawiki"
+	"golang.org/x/exp/slices"
+	"testing"
+	"time"
+)
+
+// mockRevisionRepository returns the recent changes of each poll in turn.
+type mockRevisionRepository struct {
+	polls  [][]mediawiki.Revision
+	since  []time.Time
+	latest map[string]mediawiki.Revision
+}
+
+func (m *mockRevisionRepository) GetAllByPageName(string) ([]mediawiki.Revision, error) {
+	return nil, nil
+}
+
+func (m *mockRevisionRepository) GetPagesByNames([]string) (map[string]mediawiki.Page, error) {
+	return nil, nil
+}
+
+func (m *mockRevisionRepository) NormalizeTitles([]string) (map[string]string, error) {
+	return nil, nil
+}
+
+func (m *mockRevisionRepository) GetLatestPageContent(name string) (string, error) {
+	return m.latest[name].Content, nil
+}
+
+func (m *mockRevisionRepository) GetLatestRevision(name string) (mediawiki.Revision, error) {
+	return m.latest[name], nil
+}
+
+func (m *mockRevisionRepository) GetRecentChanges(since time.Time) ([]mediawiki.Revision, error) {
+	m.since = append(m.since, since)
+
+	changes := m.polls[0]
+	m.polls = m.polls[1:]
+
+	return changes, nil
+}
+
+func Test_scanChanges(t *testing.T) {
+	tests := []struct {
+		name      string
+		polls     [][]mediawiki.Revision
+		wantFresh [][]mediawiki.RecentChangeId
+	}{
+		{
+			name: "Overlapping changes are processed once",
+			polls: [][]mediawiki.Revision{
+				{change(1, 0), change(2, time.Second)},
+				{change(1, 0), change(2, time.Second), change(3, 2*time.Second)},
+				{change(2, time.Second), change(3, 2*time.Second)},
+			},
+			wantFresh: [][]mediawiki.RecentChangeId{{1, 2}, {3}, nil},
+		},
+		{
+			name: "Burst of the same second split across polls is processed once",
+			polls: [][]mediawiki.Revision{
+				{change(1, 0), change(2, 0)},
+				{change(1, 0), change(2, 0), change(3, 0), change(4, 0)},
+				{change(1, 0), change(2, 0), change(3, 0), change(4, 0), change(5, 0)},
+			},
+			wantFresh: [][]mediawiki.RecentChangeId{{1, 2}, {3, 4}, {5}},
+		},
+		{
+			name: "Change committed late with an older timestamp is processed",
+			polls: [][]mediawiki.Revision{
+				{change(2, 10*time.Second)},
+				{change(1, 5*time.Second), change(2, 10*time.Second)},
+			},
+			wantFresh: [][]mediawiki.RecentChangeId{{2}, {1}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := &mockRevisionRepository{polls: tt.polls}
+
+			var fresh []mediawiki.RecentChangeId
+			handleChanges := func(changes []mediawiki.Revision) error {
+				for _, change := range changes {
+					fresh = append(fresh, change.RcId)
+				}
+				return nil
+			}
+
+			checkpoint := changeCheckpoint{Timestamp: checkpointStart}
+
+			for i := range tt.polls {
+				since := checkpoint.Timestamp.Add(-changeOverlap)
+
+				fresh = nil
+
+				var err error
+				checkpoint, err = scanChanges(repo, checkpoint, handleChanges)
+				if err != nil {
+					t.Fatalf("scanChanges() error = %v", err)
+				}
+
+				if !repo.since[i].Equal(since) {
+					t.Errorf("poll %d scanned since %v, want %v", i, repo.since[i], since)
+				}
+
+				if !slices.Equal(fresh, tt.wantFresh[i]) {
+					t.Errorf("poll %d processed %v, want %v", i, fresh, tt.wantFresh[i])
+				}
+			}
+		})
+	}
+}
+
+func Test_scanChanges_failedHandling(t *testing.T) {
+	repo := &mockRevisionRepository{polls: [][]mediawiki.Revision{
+		{change(1, 0), change(2, time.Second)},
+		{change(1, 0), change(2, time.Second)},
+	}}
+	last := changeCheckpoint{Timestamp: checkpointStart}
+
+	failing := func([]mediawiki.Revision) error {
+		return errors.New("list unavailable")
+	}
+
+	checkpoint, err := scanChanges(repo, last, failing)
+	if err == nil || !checkpoint.equal(last) {
+		t.Fatalf("scanChanges() = %+v, %v, want the checkpoint kept and the error", checkpoint, err)
+	}
+
+	var fresh []mediawiki.RecentChangeId
+	handleChanges := func(changes []mediawiki.Revision) error {
+		for _, change := range changes {
+			fresh = append(fresh, change.RcId)
+		}
+		return nil
+	}
+
+	if _, err := scanChanges(repo, checkpoint, handleChanges); err != nil {
+		t.Fatalf("scanChanges() error = %v", err)
+	}
+
+	if !slices.Equal(fresh, []mediawiki.RecentChangeId{1, 2}) {
+		t.Errorf("processed %v, want the changes that failed handled again", fresh)
+	}
+}
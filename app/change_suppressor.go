@@ -1,25 +1,33 @@
 package app
 
 import (
-	"freedom-sentry/mediawiki"
+	"freedom-sentry/config"
 	"freedom-sentry/suppressor"
+	"log"
 	"time"
 )
 
-func scheduleRecentChangeSuppressor(pageRepo suppressor.SuppressedPageRepository, revSuppressor suppressor.RevisionSuppressor, listUpdatedChan chan bool, revRepo suppressor.RevisionRepository) {
-	changeProcessor := make(chan []mediawiki.Revision)
+func scheduleRecentChangeSuppressor(lists *listIndexCache, revSuppressor suppressor.RevisionSuppressor, pageSuppressor suppressor.PageSuppressor, listUpdatedChan chan bool, revRepo suppressor.RevisionRepository) {
+	handleChanges := createChangesHandler([]changeHandlerFunc{
+		createHandlerForListUpdate(config.GetSuppressionListNames(), listUpdatedChan),
+		createHandlerChangeForSuppressor(lists, revSuppressor),
+		createHandlerForLogEvents(lists, pageSuppressor),
+	})
 
-	subhandlers := []changeHandlerFunc{
-		createHandlerForListUpdate(listUpdatedChan),
-		createHandlerChangeForSuppressor(pageRepo, revSuppressor),
-	}
-	handleChanges := createChangesHandler(subhandlers, changeProcessor)
+	since, _ := config.GetScanSince()
+	lastProcessed := initialCheckpoint(time.Now(), config.GetCheckpointPath(), since, config.GetMaxLookback())
 
-	go handleChanges()
+	for range time.Tick(5 * time.Second) {
+		checkpoint, err := scanChanges(revRepo, lastProcessed, handleChanges)
+		if err != nil || checkpoint.equal(lastProcessed) {
+			continue
+		}
 
-	lastProcessed := time.Now().Add(-30 * time.Minute)
+		lastProcessed = checkpoint
 
-	for range time.Tick(5 * time.Second) {
-		lastProcessed, _ = scanChanges(revRepo, lastProcessed, changeProcessor)
+		err = saveCheckpoint(config.GetCheckpointPath(), lastProcessed)
+		if err != nil {
+			log.Println("failed to save the recent changes checkpoint:", err)
+		}
 	}
 }
@@ -0,0 +1,93 @@
+package app
+
+import (
+	"errors"
+	"fmt"
+	"freedom-sentry/config"
+	"freedom-sentry/mediawiki"
+	"freedom-sentry/suppressor"
+	"log"
+	"os"
+	"text/tabwriter"
+	"time"
+)
+
+const deadLetterUsage = "usage: dead-letters list | retry [revid...] | drop revid..."
+
+func scheduleDeadLetterRetrier(queue *suppressor.DeadLetterQueue, revSuppressor suppressor.RevisionSuppressor, lists *listIndexCache) {
+	isListed := func(rev mediawiki.Revision) (bool, error) {
+		index, err := lists.get()
+		if err != nil {
+			return false, err
+		}
+
+		_, ok := index.forRevision(rev)
+
+		return ok, nil
+	}
+
+	for range time.Tick(time.Minute) {
+		err := suppressor.RetryDeadLetters(queue, revSuppressor, isListed)
+		if err != nil {
+			log.Println("dead letters are still failing:", err)
+		}
+	}
+}
+
+// runDeadLetterCommand lists or changes the dead letter queue. Retried revisions are picked up by the running
+// instance within a minute.
+func runDeadLetterCommand(args []string) error {
+	queue := suppressor.NewDeadLetterQueue(config.GetDeadLetterPath())
+
+	if len(args) == 0 {
+		return errors.New(deadLetterUsage)
+	}
+
+	ids := make([]mediawiki.RevisionId, 0, len(args)-1)
+	for _, arg := range args[1:] {
+		ids = append(ids, mediawiki.RevisionId(arg))
+	}
+
+	switch args[0] {
+	case "list":
+		return listDeadLetters(queue)
+	case "retry":
+		return queue.ScheduleNow(ids)
+	case "drop":
+		if len(ids) == 0 {
+			return errors.New(deadLetterUsage)
+		}
+
+		return queue.Drop(ids)
+	default:
+		return errors.New(deadLetterUsage)
+	}
+}
+
+func listDeadLetters(queue *suppressor.DeadLetterQueue) error {
+	letters, err := queue.GetAll()
+	if err != nil {
+		return err
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+
+	_, _ = fmt.Fprintln(w, "REVID\tTITLE\tSTATUS\tATTEMPTS\tFIRST SEEN\tLAST SEEN\tNEXT ATTEMPT\tERROR")
+	for _, l := range letters {
+		_, _ = fmt.Fprintf(w, "%s\t%s\t%s\t%d\t%s\t%s\t%s\t%s\n",
+			l.RevisionId, l.Title, deadLetterStatusText(l), l.Attempts,
+			l.FirstSeen.Format(time.RFC3339), l.LastSeen.Format(time.RFC3339), l.NextAttempt.Format(time.RFC3339),
+			l.Error)
+	}
+
+	return w.Flush()
+}
+
+// deadLetterStatusText tells whether the letter is retried, or since when and why not.
+func deadLetterStatusText(l suppressor.DeadLetter) string {
+	if l.Status == suppressor.DeadLetterPending {
+		return "pending"
+	}
+
+	return fmt.Sprintf("%s since %s: %s", l.Status, l.StatusChanged.Format(time.RFC3339), l.StatusReason)
+}
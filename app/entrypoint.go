@@ -23,42 +23,102 @@ func NewApp(opts ...util.Option[App]) *App {
 	return a
 }
 
-func (App) Run() {
-	apiEndpoint := os.Getenv(config.EnvApiEndpoint)
+func (a App) Run() {
+	api := createApi()
 
-	api := mediawiki.NewApi(apiEndpoint, http.DefaultClient, acquireCsrfTokenFn)
+	userinfo := validateAccess(api)
 
-	validateAccess(api)
+	revRepo := suppressor.NewRepository(api, suppressor.WithBotChanges(config.AreBotChangesIncluded()))
 
-	revRepo := suppressor.NewRepository(api)
+	pageRepo, listPurgeChan := suppressor.NewPageRepository(createListSource(revRepo))
+	moves := suppressor.NewMoveTracker(config.GetMovesPath())
+	titles := suppressor.NewTitleIndex(config.GetTitleIndexPath(), config.GetTitleHashSalt())
+	lists := newListIndexCache(pageRepo, moves, titles)
 
-	revSuppressor := suppressor.NewRevisionSuppressor(api)
+	deadLetters := suppressor.NewDeadLetterQueue(config.GetDeadLetterPath())
+	revSuppressor := suppressor.NewRevisionSuppressor(api, suppressor.BatchSizeForRights(userinfo.Rights), deadLetters, createHideProfileFn(lists))
 	pageSuppressor := suppressor.NewPageSuppressor(revRepo, revSuppressor)
 
-	listUpdatedChan := make(chan bool)
+	var listMaintainers []suppressor.ListMaintainer
+	if config.IsListMaintained() {
+		for _, listName := range config.GetSuppressionListNames() {
+			listMaintainers = append(listMaintainers, suppressor.NewListMaintainer(api, revRepo, moves, listName, suppressor.WithListDryRun(a.isDryMode)))
+		}
+	}
 
-	pageRepo, listPurgeChan := suppressor.NewPageRepository(revRepo, config.GetSuppressionListName())
+	listUpdatedChan := make(chan bool)
 
 	go func() {
 		for {
 			select {
 			case <-listUpdatedChan:
 				listPurgeChan <- true
-				suppressList(pageRepo, pageSuppressor)
+				suppressList(pageRepo, moves, titles, pageSuppressor)
 			}
 		}
 	}()
 
 	done := make(chan bool)
 
-	go scheduleListSuppressor(pageRepo, pageSuppressor)
-	go scheduleRecentChangeSuppressor(pageRepo, revSuppressor, listUpdatedChan, revRepo)
+	go scheduleListSuppressor(pageRepo, moves, titles, pageSuppressor, listMaintainers)
+	go scheduleListWatcher(pageRepo, listUpdatedChan)
+	go scheduleRecentChangeSuppressor(lists, revSuppressor, pageSuppressor, listUpdatedChan, revRepo)
+	go scheduleDeadLetterRetrier(deadLetters, revSuppressor, lists)
 
 	<-done
 }
 
+// createApi returns the API of the configured wiki, authenticated by the configured method.
+func createApi() mediawiki.Api {
+	apiEndpoint := os.Getenv(config.EnvApiEndpoint)
+
+	authenticator, client := createAuthenticator(apiEndpoint)
+
+	return mediawiki.NewApi(apiEndpoint, client, authenticator, acquireTokenFn,
+		mediawiki.WithMaxLag(config.GetMaxLag()),
+		mediawiki.WithWriteMaxLag(config.GetWriteMaxLag()),
+	)
+}
+
+// createListSource combines the configured wiki pages, local file and URL holding the suppression list.
+func createListSource(revRepo suppressor.RevisionRepository) suppressor.ListSource {
+	var sources []suppressor.ListSource
+
+	for _, listName := range config.GetSuppressionListNames() {
+		sources = append(sources, suppressor.NewWikiPageListSource(revRepo, listName))
+	}
+
+	if path := config.GetSuppressionListFile(); path != "" {
+		sources = append(sources, suppressor.NewFileListSource(path))
+	}
+
+	if listUrl := config.GetSuppressionListUrl(); listUrl != "" {
+		sources = append(sources, suppressor.NewUrlListSource(http.DefaultClient, listUrl))
+	}
+
+	if len(sources) == 0 {
+		panic("no suppression list is configured, set LIST_NAME, LIST_FILE or LIST_URL")
+	}
+
+	return suppressor.NewCompositeListSource(sources...)
+}
+
+// RunCommand runs a maintenance command named by the first argument instead of the service.
+func (App) RunCommand(args []string) error {
+	switch args[0] {
+	case "dead-letters":
+		return runDeadLetterCommand(args[1:])
+	case "moves":
+		return runMovesCommand(args[1:])
+	case "hash":
+		return runHashCommand(args[1:])
+	default:
+		return fmt.Errorf("unknown command: %s", args[0])
+	}
+}
+
 // validateAccess panics if the given access credentials do not provide suppression capability.
-func validateAccess(api mediawiki.Api) {
+func validateAccess(api mediawiki.Api) query.Userinfo {
 	userinfoQuery := query.UserinfoMetaQuery{Properties: []string{"rights"}}
 	action := query.Query{Meta: []query.Meta{&userinfoQuery}}
 
@@ -71,7 +131,7 @@ func validateAccess(api mediawiki.Api) {
 
 	for _, right := range userinfo.Rights {
 		if right == "suppressrevision" {
-			return
+			return userinfo
 		}
 	}
 
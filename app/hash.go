@@ -0,0 +1,54 @@
+package app
+
+import (
+	"errors"
+	"fmt"
+	"freedom-sentry/config"
+	"freedom-sentry/suppressor"
+	"os"
+	"text/tabwriter"
+)
+
+const hashUsage = "usage: hash title..."
+
+// runHashCommand prints the entries to list the titles under without revealing them, and indexes the titles
+// so that the full scan resolves the entries. The titles are normalized by the wiki first, which capitalizes
+// them and canonicalizes namespaces as configured, so that they hash like the titles of recent changes.
+func runHashCommand(args []string) error {
+	titles := suppressor.NewTitleIndex(config.GetTitleIndexPath(), config.GetTitleHashSalt())
+
+	if len(args) == 0 {
+		return errors.New(hashUsage)
+	}
+
+	if !titles.IsEnabled() {
+		return errors.New("LIST_HASH_SALT is not set, titles are not hashed without a salt")
+	}
+
+	normalized, err := suppressor.NewRepository(createApi()).NormalizeTitles(args)
+	if err != nil {
+		return err
+	}
+
+	for _, title := range args {
+		if _, ok := normalized[title]; !ok {
+			return fmt.Errorf("invalid title %q", title)
+		}
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+
+	_, _ = fmt.Fprintln(w, "ENTRY\tTITLE")
+	for _, requested := range args {
+		title := normalized[requested]
+
+		hash, err := titles.Add(title)
+		if err != nil {
+			return err
+		}
+
+		_, _ = fmt.Fprintf(w, "sha256:%s\t%s\n", hash, title)
+	}
+
+	return w.Flush()
+}
@@ -0,0 +1,91 @@
+package app
+
+import (
+	"freedom-sentry/suppressor"
+	"log"
+	"sync"
+	"time"
+)
+
+// listIndexCache keeps the index of the active entries of the list, so that looking up every recent change and
+// every batch reads neither the list nor the state files. The index is rebuilt once the version of the list
+// changes, an entry expires, or a move or a hashed title is learned.
+type listIndexCache struct {
+	pageRepo suppressor.SuppressedPageRepository
+	moves    *suppressor.MoveTracker
+	titles   *suppressor.TitleIndex
+	now      func() time.Time
+
+	lock      sync.Mutex
+	index     *suppressionIndex
+	version   suppressor.ListVersion
+	expiresAt time.Time // When the first indexed entry expires, zero if none does
+}
+
+func newListIndexCache(pageRepo suppressor.SuppressedPageRepository, moves *suppressor.MoveTracker, titles *suppressor.TitleIndex) *listIndexCache {
+	return &listIndexCache{
+		pageRepo: pageRepo,
+		moves:    moves,
+		titles:   titles,
+		now:      time.Now,
+	}
+}
+
+// get returns the index of the current version of the list.
+func (c *listIndexCache) get() (suppressionIndex, error) {
+	entries, version, err := c.pageRepo.GetAll()
+	if err != nil {
+		log.Println("failed to get suppression list:", err)
+		return suppressionIndex{}, err
+	}
+
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	now := c.now()
+
+	isExpired := !c.expiresAt.IsZero() && !now.Before(c.expiresAt)
+	if c.index != nil && version == c.version && !isExpired {
+		return *c.index, nil
+	}
+
+	active := activeEntries(entries, c.moves, c.titles, now)
+	index := newSuppressionIndex(active, c.titles)
+
+	c.index = &index
+	c.version = version
+	c.expiresAt = time.Time{}
+
+	for _, entry := range active {
+		if !entry.Expires.IsZero() && (c.expiresAt.IsZero() || entry.Expires.Before(c.expiresAt)) {
+			c.expiresAt = entry.Expires
+		}
+	}
+
+	return index, nil
+}
+
+// recordMove tracks the move of a listed page, which is looked up by its new title from now on.
+func (c *listIndexCache) recordMove(from, to string, movedAt time.Time) error {
+	defer c.invalidate()
+
+	return c.moves.Record(from, to, movedAt)
+}
+
+// indexHashedTitle adds the title of a page matching a hashed entry to the title index, so that the full scan
+// finds the page too.
+func (c *listIndexCache) indexHashedTitle(title string) {
+	if _, err := c.titles.Add(title); err != nil {
+		log.Println("failed to index the title of a hashed entry:", err)
+		return
+	}
+
+	c.invalidate()
+}
+
+func (c *listIndexCache) invalidate() {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	c.index = nil
+}
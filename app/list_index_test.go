@@ -0,0 +1,95 @@
+package app
+
+import (
+	"freedom-sentry/suppressor"
+	"testing"
+	"time"
+)
+
+func Test_listIndexCache_get(t *testing.T) {
+	pageRepo := &mockPageRepository{entries: []suppressor.ListEntry{{Title: "Listed"}}, version: "1"}
+	moves := suppressor.NewMoveTracker("")
+	lists := newListIndexCache(pageRepo, moves, suppressor.NewTitleIndex("", ""))
+
+	assertListed := func(title string, want bool) {
+		t.Helper()
+
+		index, err := lists.get()
+		if err != nil {
+			t.Fatalf("get() error = %v", err)
+		}
+
+		if _, got := index.forTitle(title); got != want {
+			t.Errorf("forTitle(%q) = %v, want %v", title, got, want)
+		}
+	}
+
+	assertListed("Listed", true)
+
+	// Moves tracked behind the back of the cache are only seen in the next version of the list
+	_ = moves.Record("Listed", "Renamed", checkpointStart)
+	assertListed("Renamed", false)
+
+	pageRepo.version = "2"
+	assertListed("Renamed", true)
+
+	if err := lists.recordMove("Renamed", "Renamed again", checkpointStart); err != nil {
+		t.Fatalf("recordMove() error = %v", err)
+	}
+	assertListed("Renamed again", true)
+}
+
+func Test_listIndexCache_get_expiry(t *testing.T) {
+	now := checkpointStart
+	pageRepo := &mockPageRepository{entries: []suppressor.ListEntry{
+		{Title: "Temporary", Expires: now.Add(time.Hour)},
+		{Title: "Permanent"},
+	}, version: "1"}
+
+	lists := newListIndexCache(pageRepo, suppressor.NewMoveTracker(""), suppressor.NewTitleIndex("", ""))
+	lists.now = func() time.Time { return now }
+
+	if index, _ := lists.get(); len(index.byTitle) != 2 {
+		t.Errorf("get() indexed %v, want both entries", index.byTitle)
+	}
+
+	now = now.Add(time.Hour)
+
+	index, _ := lists.get()
+	if _, ok := index.forTitle("Temporary"); ok {
+		t.Errorf("get() must drop the entry once it expires")
+	}
+	if _, ok := index.forTitle("Permanent"); !ok {
+		t.Errorf("get() must keep the entries which have not expired")
+	}
+}
+
+func Test_listIndexCache_hashedTitles(t *testing.T) {
+	titles := suppressor.NewTitleIndex("", "salt")
+	hash := titles.Hash("Hidden")
+
+	pageRepo := &mockPageRepository{entries: []suppressor.ListEntry{{Hash: hash}}, version: "1"}
+	lists := newListIndexCache(pageRepo, suppressor.NewMoveTracker(""), titles)
+
+	index, _ := lists.get()
+
+	entry, ok := index.forTitle("Hidden")
+	if !ok || entry.Title != "Hidden" || !index.isUnindexedHash(entry) {
+		t.Fatalf("forTitle() = %+v, %v, want the hashed entry for the title", entry, ok)
+	}
+
+	if _, isResolved, _ := titles.Resolve(hash); isResolved {
+		t.Errorf("forTitle() must not add the title to the title index")
+	}
+
+	lists.indexHashedTitle("Hidden")
+
+	if _, isResolved, _ := titles.Resolve(hash); !isResolved {
+		t.Errorf("indexHashedTitle() must add the title to the title index")
+	}
+
+	index, _ = lists.get()
+	if entry, ok := index.forTitle("Hidden"); !ok || index.isUnindexedHash(entry) {
+		t.Errorf("forTitle() = %+v, %v, want the entry resolved through the title index", entry, ok)
+	}
+}
@@ -7,24 +7,130 @@ import (
 	"time"
 )
 
-func scheduleListSuppressor(pageRepo suppressor.SuppressedPageRepository, pageSuppressor suppressor.PageSuppressor) {
+func scheduleListSuppressor(pageRepo suppressor.SuppressedPageRepository, moves *suppressor.MoveTracker, titles *suppressor.TitleIndex, pageSuppressor suppressor.PageSuppressor, listMaintainers []suppressor.ListMaintainer) {
 	if !config.IsInitFullscanSkipped() {
-		suppressList(pageRepo, pageSuppressor)
+		suppressList(pageRepo, moves, titles, pageSuppressor)
+		maintainLists(listMaintainers)
 	}
 
 	for range time.Tick(15 * time.Minute) {
-		suppressList(pageRepo, pageSuppressor)
+		suppressList(pageRepo, moves, titles, pageSuppressor)
+		maintainLists(listMaintainers)
 	}
 }
 
-func suppressList(pageRepo suppressor.SuppressedPageRepository, pageSuppressor suppressor.PageSuppressor) {
-	log.Println("running a new suppression job")
+// scheduleListWatcher signals changes of the list sources that are not edits to wiki pages, e.g. of a local file.
+func scheduleListWatcher(pageRepo suppressor.SuppressedPageRepository, listUpdatedChan chan bool) {
+	for range time.Tick(time.Minute) {
+		hasChanged, err := pageRepo.HasChanged()
+		if err != nil {
+			log.Println("failed to check the suppression list for changes:", err)
+			continue
+		}
 
-	suppressedPages, err := pageRepo.GetAll()
-	for _, pageName := range suppressedPages {
-		err = pageSuppressor.SuppressPageByName(pageName)
+		if hasChanged {
+			listUpdatedChan <- true
+		}
+	}
+}
+
+// maintainLists corrects the suppression list pages, if enabled.
+func maintainLists(listMaintainers []suppressor.ListMaintainer) {
+	for _, listMaintainer := range listMaintainers {
+		err := listMaintainer.MaintainList()
 		if err != nil {
-			log.Printf("failed to suppress [%s] revisions: %v", pageName, err)
+			log.Println("failed to correct the suppression list:", err)
+		}
+	}
+}
+
+func suppressList(pageRepo suppressor.SuppressedPageRepository, moves *suppressor.MoveTracker, titles *suppressor.TitleIndex, pageSuppressor suppressor.PageSuppressor) {
+	log.Println("running a new suppression job")
+
+	reportMoves(moves)
+
+	entries, err := getActiveEntries(pageRepo, moves, titles)
+	if err != nil {
+		return
+	}
+
+	reportUnresolvedHashes(entries)
+
+	err = pageSuppressor.SuppressEntries(entries)
+	if err != nil {
+		log.Println("suppression job finished with errors:", err)
+	}
+}
+
+// getActiveEntries returns the active entries of the list, see activeEntries.
+func getActiveEntries(pageRepo suppressor.SuppressedPageRepository, moves *suppressor.MoveTracker, titles *suppressor.TitleIndex) ([]suppressor.ListEntry, error) {
+	entries, _, err := pageRepo.GetAll()
+	if err != nil {
+		log.Println("failed to get suppression list:", err)
+		return nil, err
+	}
+
+	return activeEntries(entries, moves, titles, time.Now()), nil
+}
+
+// activeEntries returns the entries which have not expired, with titles of hashed entries resolved through
+// the title index and titles of moved pages replaced by their current titles.
+func activeEntries(entries []suppressor.ListEntry, moves *suppressor.MoveTracker, titles *suppressor.TitleIndex, now time.Time) []suppressor.ListEntry {
+	allMoves, err := moves.GetAll()
+	if err != nil {
+		log.Println("failed to read moves of listed pages:", err)
+	}
+
+	currentTitles := make(map[string]string, len(allMoves))
+	for _, move := range allMoves {
+		currentTitles[move.From] = move.To
+	}
+
+	active := make([]suppressor.ListEntry, 0, len(entries))
+
+	for _, entry := range entries {
+		if entry.IsExpired(now) {
+			continue
+		}
+
+		if entry.Hash != "" {
+			title, isResolved, err := titles.Resolve(entry.Hash)
+			if err != nil {
+				log.Println("failed to read the title index:", err)
+			}
+			if isResolved {
+				entry.Title = title
+			}
+		}
+
+		if current, isMoved := currentTitles[entry.Title]; isMoved {
+			entry.Title = current
+		}
+
+		active = append(active, entry)
+	}
+
+	return active
+}
+
+// reportMoves logs the tracked moves of listed pages, so that the list can be corrected.
+func reportMoves(moves *suppressor.MoveTracker) {
+	allMoves, err := moves.GetAll()
+	if err != nil {
+		log.Println("failed to read moves of listed pages:", err)
+		return
+	}
+
+	for _, move := range allMoves {
+		log.Printf("listed page [%s] has been moved to [%s], the list needs to be updated", move.From, move.To)
+	}
+}
+
+// reportUnresolvedHashes logs hashed entries the full scan cannot suppress, as their titles are not indexed yet.
+func reportUnresolvedHashes(entries []suppressor.ListEntry) {
+	for _, entry := range entries {
+		if entry.Hash != "" && entry.Title == "" {
+			log.Printf("hashed entry %s has no indexed title, it is matched in recent changes only until the title is hashed with the hash command", entry.Hash)
 		}
 	}
 }
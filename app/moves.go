@@ -0,0 +1,51 @@
+package app
+
+import (
+	"errors"
+	"fmt"
+	"freedom-sentry/config"
+	"freedom-sentry/suppressor"
+	"os"
+	"text/tabwriter"
+	"time"
+)
+
+const movesUsage = "usage: moves list | forget title..."
+
+// runMovesCommand lists the tracked moves of listed pages or forgets those already corrected in the list.
+func runMovesCommand(args []string) error {
+	moves := suppressor.NewMoveTracker(config.GetMovesPath())
+
+	if len(args) == 0 {
+		return errors.New(movesUsage)
+	}
+
+	switch args[0] {
+	case "list":
+		return listMoves(moves)
+	case "forget":
+		if len(args) == 1 {
+			return errors.New(movesUsage)
+		}
+
+		return moves.Forget(args[1:])
+	default:
+		return errors.New(movesUsage)
+	}
+}
+
+func listMoves(moves *suppressor.MoveTracker) error {
+	all, err := moves.GetAll()
+	if err != nil {
+		return err
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+
+	_, _ = fmt.Fprintln(w, "LISTED TITLE\tCURRENT TITLE\tMOVED AT")
+	for _, m := range all {
+		_, _ = fmt.Fprintf(w, "%s\t%s\t%s\n", m.From, m.To, m.MovedAt.Format(time.RFC3339))
+	}
+
+	return w.Flush()
+}
@@ -2,6 +2,8 @@ package app
 
 import "freedom-sentry/util"
 
+// WithDryMode makes the list maintainers log their corrections as a diff instead of saving them. Revisions are
+// suppressed either way.
 func WithDryMode(isDryMode bool) util.Option[App] {
 	return func(a *App) {
 		a.isDryMode = isDryMode
@@ -3,16 +3,65 @@ package config
 import (
 	"flag"
 	"os"
+	"path/filepath"
+	"strings"
+	"time"
 )
 
 const EnvAccessToken = "ACCESS_TOKEN"
+const EnvAccessSecret = "ACCESS_SECRET"
+const EnvClientKey = "CLIENT_KEY"
+const EnvClientSecret = "CLIENT_SECRET"
+const envAuthMethod = "AUTH_METHOD"
+const EnvLoginName = "LOGIN_NAME"
+const EnvLoginPassword = "LOGIN_PASSWORD"
 const EnvApiEndpoint = "API_ENDPOINT"
 const envSuppressionListName = "LIST_NAME"
+const envSuppressionListFile = "LIST_FILE"
+const envSuppressionListUrl = "LIST_URL"
+const envStateDir = "STATE_DIR"
+const envTitleHashSalt = "LIST_HASH_SALT"
+
+const deadLetterFileName = "dead_letters.json"
+const checkpointFileName = "scan_checkpoint.json"
+const movesFileName = "moves.json"
+const titleIndexFileName = "title_index.json"
+
+const defaultMaxLookback = 24 * time.Hour
+const defaultMaxLag = 5
+const defaultWriteMaxLag = 15
+
+const (
+	// AuthMethodOAuth2 sends ACCESS_TOKEN as a bearer token
+	AuthMethodOAuth2 = "oauth2"
+	// AuthMethodOAuth1 signs requests with CLIENT_KEY, CLIENT_SECRET, ACCESS_TOKEN and ACCESS_SECRET
+	AuthMethodOAuth1 = "oauth1"
+	// AuthMethodBotPassword logs in as LOGIN_NAME with the bot password LOGIN_PASSWORD
+	AuthMethodBotPassword = "botpassword"
+)
 
 var isInitFullscanSkipped bool
+var areBotChangesIncluded bool
+var isListMaintained bool
+var isListDryRun bool
+var scanSince time.Time
+var maxLookback time.Duration
+var maxLag int
+var writeMaxLag int
 
 func InitFlags() {
 	flag.BoolVar(&isInitFullscanSkipped, "skip-init-fullscan", false, "")
+	flag.BoolVar(&areBotChangesIncluded, "include-bot-changes", false, "scan recent changes flagged as bot edits too")
+	flag.BoolVar(&isListMaintained, "maintain-list", false, "replace moved titles and mark missing pages in the suppression list")
+	flag.BoolVar(&isListDryRun, "list-dry-run", false, "log corrections of the suppression list as a diff instead of saving them, revisions are suppressed regardless")
+	flag.Func("since", "RFC 3339 time to scan recent changes from, overriding the saved checkpoint", func(value string) error {
+		var err error
+		scanSince, err = time.Parse(time.RFC3339, value)
+		return err
+	})
+	flag.DurationVar(&maxLookback, "max-lookback", defaultMaxLookback, "how far back in time the scan of recent changes may start")
+	flag.IntVar(&maxLag, "maxlag", defaultMaxLag, "seconds of replication lag to pause reads at, 0 to disable")
+	flag.IntVar(&writeMaxLag, "write-maxlag", defaultWriteMaxLag, "seconds of replication lag to reject suppressions at, 0 to disable")
 
 	flag.Parse()
 }
@@ -21,6 +70,101 @@ func IsInitFullscanSkipped() bool {
 	return isInitFullscanSkipped
 }
 
-func GetSuppressionListName() string {
-	return os.Getenv(envSuppressionListName)
+func AreBotChangesIncluded() bool {
+	return areBotChangesIncluded
+}
+
+func IsListMaintained() bool {
+	return isListMaintained
+}
+
+func IsListDryRun() bool {
+	return isListDryRun
+}
+
+// GetSuppressionListNames returns the wiki pages holding the suppression list, separated by | in LIST_NAME.
+func GetSuppressionListNames() []string {
+	var names []string
+	for _, name := range strings.Split(os.Getenv(envSuppressionListName), "|") {
+		if name = strings.TrimSpace(name); name != "" {
+			names = append(names, name)
+		}
+	}
+
+	return names
+}
+
+// GetSuppressionListFile returns the local file holding a suppression list that is not published on the wiki.
+func GetSuppressionListFile() string {
+	return os.Getenv(envSuppressionListFile)
+}
+
+// GetSuppressionListUrl returns the URL to download a suppression list from.
+func GetSuppressionListUrl() string {
+	return os.Getenv(envSuppressionListUrl)
+}
+
+// GetAuthMethod returns how requests to the API are authenticated, OAuth 2 by default.
+func GetAuthMethod() string {
+	if method := os.Getenv(envAuthMethod); method != "" {
+		return method
+	}
+
+	return AuthMethodOAuth2
+}
+
+// GetCommandArgs returns the arguments left after the flags, naming a command to run instead of the service.
+func GetCommandArgs() []string {
+	return flag.Args()
+}
+
+// GetStateDir returns the directory for files that must survive restarts, the working directory by default.
+func GetStateDir() string {
+	if dir := os.Getenv(envStateDir); dir != "" {
+		return dir
+	}
+
+	return "."
+}
+
+func GetDeadLetterPath() string {
+	return filepath.Join(GetStateDir(), deadLetterFileName)
+}
+
+func GetMovesPath() string {
+	return filepath.Join(GetStateDir(), movesFileName)
+}
+
+// GetTitleIndexPath returns the file resolving hashed entries of the list to titles, which must stay private.
+func GetTitleIndexPath() string {
+	return filepath.Join(GetStateDir(), titleIndexFileName)
+}
+
+// GetTitleHashSalt returns the secret salt of hashed titles in the list.
+func GetTitleHashSalt() string {
+	return os.Getenv(envTitleHashSalt)
+}
+
+func GetCheckpointPath() string {
+	return filepath.Join(GetStateDir(), checkpointFileName)
+}
+
+// GetScanSince returns the time the scan of recent changes was told to start from, if any.
+func GetScanSince() (time.Time, bool) {
+	return scanSince, !scanSince.IsZero()
+}
+
+// GetMaxLookback returns the ceiling for the age of the position the scan of recent changes resumes from.
+func GetMaxLookback() time.Duration {
+	return maxLookback
+}
+
+// GetMaxLag returns the maxlag parameter of reads.
+func GetMaxLag() int {
+	return maxLag
+}
+
+// GetWriteMaxLag returns the maxlag parameter of suppressions, which are more urgent than reads.
+func GetWriteMaxLag() int {
+	return writeMaxLag
 }
@@ -57,22 +57,26 @@ func (j *emptyJar) Cookies(_ *url.URL) (cookies []*gohttp.Cookie) {
 	return cookies
 }
 
-var DefaultClient = makeClient()
+// DefaultClient drops cookies, requests are authenticated with tokens or signatures.
+var DefaultClient = makeClient(&emptyJar{})
 
-func makeClient() Client {
-	return &retryClient{
-		client: &ratelimitClient{
-			client: &defaultClient{
-				client: &gohttp.Client{
-					Timeout: time.Second * 30,
-					Transport: &gohttp.Transport{
+// NewSessionClient keeps cookies in the jar, e.g. the session of a login.
+func NewSessionClient(jar gohttp.CookieJar) Client {
+	return makeClient(jar)
+}
+
+func makeClient(jar gohttp.CookieJar) Client {
+	return NewRetryClient(&ratelimitClient{
+		client: &defaultClient{
+			client: &gohttp.Client{
+				Timeout: time.Second * 30,
+				Transport: &gohttp.Transport{
 
-						DisableKeepAlives: true,
-					},
-					Jar: &emptyJar{},
+					DisableKeepAlives: true,
 				},
+				Jar: jar,
 			},
-			limiter: rate.NewLimiter(rate.Every(maxConnectionsWindow), maxConnections),
 		},
-	}
+		limiter: rate.NewLimiter(rate.Every(maxConnectionsWindow), maxConnections),
+	}, DefaultRetryPolicy)
 }
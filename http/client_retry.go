@@ -1,37 +1,204 @@
 package http
 
 import (
+	"context"
+	"errors"
+	"io"
 	"log"
+	"math/rand"
 	"net/http"
+	"strconv"
+	"time"
 )
 
+// RetryPolicy decides which requests are sent again and how long to wait before.
+type RetryPolicy struct {
+	// MaxAttempts limits the requests sent, including the first one
+	MaxAttempts int
+	// BaseDelay is the ceiling of the first backoff, doubled with every attempt up to MaxDelay. The delay is
+	// picked at random below the ceiling ("full jitter").
+	BaseDelay time.Duration
+	MaxDelay  time.Duration
+	// RetryableStatuses are the status codes of responses to retry
+	RetryableStatuses []int
+	// IsRetryableError decides whether a request failing with the error is retried
+	IsRetryableError func(err error) bool
+}
+
+// DefaultRetryPolicy retries failed connections and responses of overloaded servers.
+var DefaultRetryPolicy = RetryPolicy{
+	MaxAttempts: 4,
+	BaseDelay:   500 * time.Millisecond,
+	MaxDelay:    30 * time.Second,
+	RetryableStatuses: []int{
+		http.StatusTooManyRequests,
+		http.StatusBadGateway,
+		http.StatusServiceUnavailable,
+		http.StatusGatewayTimeout,
+	},
+	IsRetryableError: func(err error) bool {
+		return !errors.Is(err, context.Canceled)
+	},
+}
+
+type retryHookKey struct{}
+
+// WithRetryHook returns a copy of the context making the retry client pass every request it sends again to fn
+// first, e.g. to sign it anew as a signature must not be reused. The body of the request is not rebuilt.
+func WithRetryHook(ctx context.Context, fn func(retry *http.Request) error) context.Context {
+	return context.WithValue(ctx, retryHookKey{}, fn)
+}
+
+// NewRetryClient sends requests again according to the policy. Requests with a body are only retried if
+// the body can be replayed through GetBody.
+func NewRetryClient(client Client, policy RetryPolicy) Client {
+	return &retryClient{
+		client: client,
+		policy: policy,
+		sleep:  time.Sleep,
+		now:    time.Now,
+		jitter: rand.Int63n,
+	}
+}
+
 type retryClient struct {
 	client Client
+	policy RetryPolicy
+
+	sleep  func(time.Duration)
+	now    func() time.Time
+	jitter func(n int64) int64
 }
 
 func (c *retryClient) Do(req *http.Request) (*http.Response, error) {
-	const maxAttempts = 2
+	for attempt := 1; ; attempt++ {
+		resp, err := c.client.Do(req)
+
+		delay, isRetryable := c.delay(attempt, resp, err)
+		if !isRetryable {
+			return resp, err
+		}
+
+		if !isReplayable(req) {
+			log.Printf("not retrying %s %s, the body cannot be sent again", req.Method, req.URL)
+			return resp, err
+		}
+
+		if resp != nil {
+			_, _ = io.Copy(io.Discard, resp.Body)
+			_ = resp.Body.Close()
+		}
 
-	attempts := maxAttempts
+		log.Printf("failed attempt %d to request %s %s, waiting %s", attempt, req.Method, req.URL, delay)
 
-	var resp *http.Response
-	var err error
+		c.sleep(delay)
 
-	for attempts > 0 {
-		resp, err = c.client.Do(req)
-		if err == nil {
-			return resp, nil
+		// Rewound after waiting, so that the hook prepares the request at the time it is sent
+		req, err = rewind(req)
+		if err != nil {
+			return nil, err
 		}
+	}
+}
 
-		if resp != nil && resp.StatusCode >= 400 {
-			return resp, err
+// delay returns how long to wait before the next attempt, if the request is to be retried.
+func (c *retryClient) delay(attempt int, resp *http.Response, err error) (time.Duration, bool) {
+	if attempt >= c.policy.MaxAttempts {
+		return 0, false
+	}
+
+	if err != nil {
+		return c.backoff(attempt), c.policy.IsRetryableError != nil && c.policy.IsRetryableError(err)
+	}
+
+	if !c.isRetryableStatus(resp.StatusCode) {
+		return 0, false
+	}
+
+	if retryAfter, ok := c.retryAfter(resp); ok {
+		// A server asking to wait longer than the policy allows is not retried
+		return retryAfter, retryAfter <= c.policy.MaxDelay
+	}
+
+	return c.backoff(attempt), true
+}
+
+// backoff picks a delay below the exponentially growing ceiling of the attempt.
+func (c *retryClient) backoff(attempt int) time.Duration {
+	ceiling := c.policy.BaseDelay
+	for i := 1; i < attempt && ceiling < c.policy.MaxDelay; i++ {
+		ceiling *= 2
+	}
+
+	if ceiling > c.policy.MaxDelay {
+		ceiling = c.policy.MaxDelay
+	}
+
+	if ceiling <= 0 {
+		return 0
+	}
+
+	return time.Duration(c.jitter(int64(ceiling)))
+}
+
+func (c *retryClient) isRetryableStatus(status int) bool {
+	for _, retryable := range c.policy.RetryableStatuses {
+		if status == retryable {
+			return true
+		}
+	}
+
+	return false
+}
+
+// retryAfter reads the Retry-After header, given either in seconds or as an HTTP date.
+func (c *retryClient) retryAfter(resp *http.Response) (time.Duration, bool) {
+	value := resp.Header.Get("Retry-After")
+	if value == "" {
+		return 0, false
+	}
+
+	if seconds, err := strconv.Atoi(value); err == nil && seconds >= 0 {
+		return time.Duration(seconds) * time.Second, true
+	}
+
+	date, err := http.ParseTime(value)
+	if err != nil {
+		return 0, false
+	}
+
+	delay := date.Sub(c.now())
+	if delay < 0 {
+		delay = 0
+	}
+
+	return delay, true
+}
+
+func isReplayable(req *http.Request) bool {
+	return req.Body == nil || req.Body == http.NoBody || req.GetBody != nil
+}
+
+// rewind returns a copy of the request with a fresh body, the body of the request sent is consumed. The copy
+// is passed to the hook of the request context, if any.
+func rewind(req *http.Request) (*http.Request, error) {
+	retry := req.Clone(req.Context())
+
+	if req.Body != nil && req.Body != http.NoBody {
+		body, err := req.GetBody()
+		if err != nil {
+			return nil, err
 		}
 
-		sleepTime := 2 ^ (maxAttempts - attempts)
-		attempts--
+		retry.Body = body
+	}
 
-		log.Printf("failed attempt %d to request %s %s, waiting %d s", maxAttempts-attempts, req.Method, req.URL, sleepTime)
+	if hook, ok := req.Context().Value(retryHookKey{}).(func(*http.Request) error); ok {
+		err := hook(retry)
+		if err != nil {
+			return nil, err
+		}
 	}
 
-	return resp, err
+	return retry, nil
 }
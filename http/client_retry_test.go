@@ -0,0 +1,278 @@
+package http
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+type attempt struct {
+	status  int
+	headers http.Header
+	err     error
+}
+
+type mockClient struct {
+	attempts       []attempt
+	bodies         []string
+	authorizations []string
+}
+
+func (m *mockClient) Do(req *http.Request) (*http.Response, error) {
+	var body []byte
+	if req.Body != nil {
+		body, _ = io.ReadAll(req.Body)
+	}
+	m.bodies = append(m.bodies, string(body))
+	m.authorizations = append(m.authorizations, req.Header.Get("Authorization"))
+
+	a := m.attempts[len(m.bodies)-1]
+	if a.err != nil {
+		return nil, a.err
+	}
+
+	return &http.Response{
+		StatusCode: a.status,
+		Header:     a.headers,
+		Body:       io.NopCloser(strings.NewReader("response")),
+	}, nil
+}
+
+// fakeClock records the delays instead of sleeping
+type fakeClock struct {
+	now    time.Time
+	sleeps []time.Duration
+}
+
+func (c *fakeClock) sleep(d time.Duration) {
+	c.sleeps = append(c.sleeps, d)
+	c.now = c.now.Add(d)
+}
+
+var testPolicy = RetryPolicy{
+	MaxAttempts:       4,
+	BaseDelay:         time.Second,
+	MaxDelay:          5 * time.Second,
+	RetryableStatuses: []int{http.StatusServiceUnavailable},
+	IsRetryableError: func(err error) bool {
+		return !errors.Is(err, errPermanent)
+	},
+}
+
+var errTemporary = errors.New("connection reset")
+var errPermanent = errors.New("permanent")
+
+func Test_retryClient_Do(t *testing.T) {
+	start := time.Date(2022, 4, 20, 12, 13, 14, 0, time.UTC)
+
+	tests := []struct {
+		name       string
+		policy     RetryPolicy
+		attempts   []attempt
+		noGetBody  bool
+		wantStatus int
+		wantErr    error
+		wantSleeps []time.Duration
+	}{
+		{
+			name:       "Success is not retried",
+			policy:     testPolicy,
+			attempts:   []attempt{{status: http.StatusOK}},
+			wantStatus: http.StatusOK,
+		},
+		{
+			name:       "Status not retryable",
+			policy:     testPolicy,
+			attempts:   []attempt{{status: http.StatusInternalServerError}},
+			wantStatus: http.StatusInternalServerError,
+		},
+		{
+			name:       "Exponential backoff up to the maximum delay",
+			policy:     testPolicy,
+			attempts:   []attempt{{err: errTemporary}, {status: http.StatusServiceUnavailable}, {err: errTemporary}, {status: http.StatusOK}},
+			wantStatus: http.StatusOK,
+			wantSleeps: []time.Duration{time.Second, 2 * time.Second, 4 * time.Second},
+		},
+		{
+			name:       "Gives up after the maximum attempts",
+			policy:     testPolicy,
+			attempts:   []attempt{{err: errTemporary}, {err: errTemporary}, {err: errTemporary}, {err: errTemporary}},
+			wantErr:    errTemporary,
+			wantSleeps: []time.Duration{time.Second, 2 * time.Second, 4 * time.Second},
+		},
+		{
+			name: "Backoff is capped",
+			policy: RetryPolicy{
+				MaxAttempts:      5,
+				BaseDelay:        2 * time.Second,
+				MaxDelay:         5 * time.Second,
+				IsRetryableError: testPolicy.IsRetryableError,
+			},
+			attempts:   []attempt{{err: errTemporary}, {err: errTemporary}, {err: errTemporary}, {err: errTemporary}, {status: http.StatusOK}},
+			wantStatus: http.StatusOK,
+			wantSleeps: []time.Duration{2 * time.Second, 4 * time.Second, 5 * time.Second, 5 * time.Second},
+		},
+		{
+			name:     "Error not retryable",
+			policy:   testPolicy,
+			attempts: []attempt{{err: errPermanent}},
+			wantErr:  errPermanent,
+		},
+		{
+			name:   "Retry-After in seconds",
+			policy: testPolicy,
+			attempts: []attempt{
+				{status: http.StatusServiceUnavailable, headers: http.Header{"Retry-After": {"3"}}},
+				{status: http.StatusOK},
+			},
+			wantStatus: http.StatusOK,
+			wantSleeps: []time.Duration{3 * time.Second},
+		},
+		{
+			name:   "Retry-After as a date",
+			policy: testPolicy,
+			attempts: []attempt{
+				{status: http.StatusServiceUnavailable, headers: http.Header{"Retry-After": {start.Add(4 * time.Second).Format(http.TimeFormat)}}},
+				{status: http.StatusOK},
+			},
+			wantStatus: http.StatusOK,
+			wantSleeps: []time.Duration{4 * time.Second},
+		},
+		{
+			name:   "Retry-After beyond the maximum delay is not waited for",
+			policy: testPolicy,
+			attempts: []attempt{
+				{status: http.StatusServiceUnavailable, headers: http.Header{"Retry-After": {"60"}}},
+			},
+			wantStatus: http.StatusServiceUnavailable,
+		},
+		{
+			name:      "Body that cannot be replayed is not retried",
+			policy:    testPolicy,
+			attempts:  []attempt{{err: errTemporary}},
+			noGetBody: true,
+			wantErr:   errTemporary,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mock := &mockClient{attempts: tt.attempts}
+			clock := &fakeClock{now: start}
+
+			client := NewRetryClient(mock, tt.policy).(*retryClient)
+			client.sleep = clock.sleep
+			client.now = func() time.Time { return clock.now }
+			// Waiting the whole ceiling makes the backoffs predictable
+			client.jitter = func(n int64) int64 { return n }
+
+			req, _ := http.NewRequest(http.MethodPost, "https://example.org/w/api.php", strings.NewReader("action=query"))
+			if tt.noGetBody {
+				req.GetBody = nil
+			}
+
+			resp, err := client.Do(req)
+
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("Do() error = %v, want %v", err, tt.wantErr)
+			}
+
+			if tt.wantStatus != 0 && (resp == nil || resp.StatusCode != tt.wantStatus) {
+				t.Errorf("Do() = %v, want status %d", resp, tt.wantStatus)
+			}
+
+			if len(clock.sleeps) != len(tt.wantSleeps) {
+				t.Fatalf("waited %v, want %v", clock.sleeps, tt.wantSleeps)
+			}
+			for i := range clock.sleeps {
+				if clock.sleeps[i] != tt.wantSleeps[i] {
+					t.Errorf("waited %v, want %v", clock.sleeps, tt.wantSleeps)
+				}
+			}
+
+			if len(mock.bodies) != len(tt.attempts) {
+				t.Errorf("sent %d requests, want %d", len(mock.bodies), len(tt.attempts))
+			}
+			for i, body := range mock.bodies {
+				if body != "action=query" {
+					t.Errorf("attempt %d sent body %q, want the body replayed", i+1, body)
+				}
+			}
+		})
+	}
+}
+
+func Test_retryClient_Do_retryHook(t *testing.T) {
+	mock := &mockClient{attempts: []attempt{{err: errTemporary}, {status: http.StatusServiceUnavailable}, {status: http.StatusOK}}}
+
+	client := NewRetryClient(mock, testPolicy).(*retryClient)
+	client.sleep = func(time.Duration) {}
+
+	retries := 0
+	ctx := WithRetryHook(context.Background(), func(retry *http.Request) error {
+		retries++
+		retry.Header.Set("Authorization", fmt.Sprintf("retry %d", retries))
+		return nil
+	})
+
+	req, _ := http.NewRequestWithContext(ctx, http.MethodPost, "https://example.org/w/api.php", strings.NewReader("action=query"))
+	req.Header.Set("Authorization", "original")
+
+	if _, err := client.Do(req); err != nil {
+		t.Fatalf("Do() error = %v", err)
+	}
+
+	want := []string{"original", "retry 1", "retry 2"}
+	if !reflect.DeepEqual(mock.authorizations, want) {
+		t.Errorf("sent authorizations %v, want %v", mock.authorizations, want)
+	}
+
+	if req.Header.Get("Authorization") != "original" {
+		t.Errorf("the hook must not change the original request")
+	}
+}
+
+func Test_retryClient_Do_failingRetryHook(t *testing.T) {
+	mock := &mockClient{attempts: []attempt{{err: errTemporary}, {status: http.StatusOK}}}
+
+	client := NewRetryClient(mock, testPolicy).(*retryClient)
+	client.sleep = func(time.Duration) {}
+
+	ctx := WithRetryHook(context.Background(), func(*http.Request) error {
+		return errPermanent
+	})
+
+	req, _ := http.NewRequestWithContext(ctx, http.MethodGet, "https://example.org/w/api.php", nil)
+
+	if _, err := client.Do(req); !errors.Is(err, errPermanent) {
+		t.Errorf("Do() error = %v, want %v", err, errPermanent)
+	}
+
+	if len(mock.bodies) != 1 {
+		t.Errorf("sent %d requests, want 1", len(mock.bodies))
+	}
+}
+
+func Test_retryClient_backoff_fullJitter(t *testing.T) {
+	client := NewRetryClient(&mockClient{}, testPolicy).(*retryClient)
+
+	var ceilings []int64
+	client.jitter = func(n int64) int64 {
+		ceilings = append(ceilings, n)
+		return n / 2
+	}
+
+	if got := client.backoff(3); got != 2*time.Second {
+		t.Errorf("backoff() = %v, want a delay picked below the ceiling", got)
+	}
+
+	if len(ceilings) != 1 || ceilings[0] != int64(4*time.Second) {
+		t.Errorf("jitter drew below %v, want [4s]", ceilings)
+	}
+}
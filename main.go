@@ -11,10 +11,20 @@ import _ "net/http/pprof"
 func main() {
 	config.InitFlags()
 
+	a := app.NewApp(app.WithDryMode(config.IsListDryRun()))
+
+	if args := config.GetCommandArgs(); len(args) > 0 {
+		err := a.RunCommand(args)
+		if err != nil {
+			log.Fatal(err)
+		}
+
+		return
+	}
+
 	go func() {
 		log.Println(http.ListenAndServe("localhost:6060", nil))
 	}()
 
-	a := app.NewApp(app.WithDryMode(false))
 	a.Run()
 }
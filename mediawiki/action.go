@@ -5,3 +5,8 @@ type Action interface {
 	ToActionPayload() map[string]interface{}
 	SetResponse(json map[string]interface{}) error
 }
+
+// ValidatingAction is implemented by actions that can detect an invalid payload before it is sent.
+type ValidatingAction interface {
+	Validate() error
+}
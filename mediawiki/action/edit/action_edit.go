@@ -0,0 +1,111 @@
+package edit
+
+import (
+	"errors"
+	"fmt"
+	"freedom-sentry/mediawiki"
+	"time"
+)
+
+const actionName = "edit"
+const resultSuccess = "Success"
+
+// Edit replaces the text of a page. If BaseRevisionId is set, the API rejects the edit with
+// mediawiki.ErrEditConflict when the page has been changed after that revision.
+type Edit struct {
+	Title   string
+	Text    string
+	Summary string
+	// BaseRevisionId is the latest revision of the page the text is based on
+	BaseRevisionId mediawiki.RevisionId
+	// StartTimestamp is when the text was retrieved, to detect deletions of the page since
+	StartTimestamp time.Time
+	Minor          bool
+	Bot            bool
+	// NoCreate fails the edit instead of creating the page if it does not exist
+	NoCreate bool
+
+	result Result
+}
+
+// Result is the outcome of a successful edit.
+type Result struct {
+	PageId        mediawiki.PageId
+	Title         string
+	OldRevisionId mediawiki.RevisionId
+	NewRevisionId mediawiki.RevisionId
+	// IsNoChange is set if the text was the same, no revision is created then
+	IsNoChange bool
+}
+
+func (Edit) IsWriteAction() bool {
+	return true
+}
+
+func (a Edit) Validate() error {
+	if a.Title == "" {
+		return errors.New("edit requires a title")
+	}
+
+	return nil
+}
+
+func (a Edit) ToActionPayload() map[string]interface{} {
+	payload := map[string]interface{}{
+		"action":   actionName,
+		"title":    a.Title,
+		"text":     a.Text,
+		"summary":  a.Summary,
+		"minor":    a.Minor,
+		"bot":      a.Bot,
+		"nocreate": a.NoCreate,
+	}
+
+	if a.BaseRevisionId != "" {
+		payload["baserevid"] = a.BaseRevisionId
+	}
+
+	if !a.StartTimestamp.IsZero() {
+		payload["starttimestamp"] = a.StartTimestamp.UTC().Format(time.RFC3339)
+	}
+
+	return payload
+}
+
+func (a *Edit) SetResponse(payload map[string]interface{}) error {
+	rawResult, ok := payload[actionName].(map[string]interface{})
+	if !ok {
+		return errors.New("response does not contain `edit` or invalid structure")
+	}
+
+	status, _ := rawResult["result"].(string)
+	if status != resultSuccess {
+		// Edits stopped by extensions, e.g. a captcha or an abuse filter, are not reported as API errors
+		return fmt.Errorf("edit of [%s] was not saved, result: %s", a.Title, status)
+	}
+
+	result := Result{}
+	result.Title, _ = rawResult["title"].(string)
+
+	if pageId, ok := rawResult["pageid"].(float64); ok {
+		result.PageId = mediawiki.PageId(pageId)
+	}
+
+	_, result.IsNoChange = rawResult["nochange"]
+
+	if oldRevId, ok := rawResult["oldrevid"]; ok {
+		result.OldRevisionId = mediawiki.RevisionIdFromAny(oldRevId)
+	}
+
+	if newRevId, ok := rawResult["newrevid"]; ok {
+		result.NewRevisionId = mediawiki.RevisionIdFromAny(newRevId)
+	}
+
+	a.result = result
+
+	return nil
+}
+
+func (a Edit) GetResult() Result {
+	return a.result
+}
@@ -0,0 +1,111 @@
+package edit
+
+import (
+	"encoding/json"
+	"freedom-sentry/mediawiki"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestEdit_ToActionPayload(t *testing.T) {
+	tests := []struct {
+		name   string
+		action Edit
+		want   map[string]interface{}
+	}{
+		{
+			name:   "Empty",
+			action: Edit{},
+			want: map[string]interface{}{
+				"action":   actionName,
+				"title":    "",
+				"text":     "",
+				"summary":  "",
+				"minor":    false,
+				"bot":      false,
+				"nocreate": false,
+			},
+		},
+		{
+			name: "Edit based on a revision",
+			action: Edit{
+				Title:          "Dummy Title",
+				Text:           "Dummy text",
+				Summary:        "Dummy summary",
+				BaseRevisionId: "42",
+				StartTimestamp: time.Date(2022, 4, 20, 14, 13, 14, 0, time.FixedZone("CEST", 2*60*60)),
+				NoCreate:       true,
+			},
+			want: map[string]interface{}{
+				"action":         actionName,
+				"title":          "Dummy Title",
+				"text":           "Dummy text",
+				"summary":        "Dummy summary",
+				"minor":          false,
+				"bot":            false,
+				"nocreate":       true,
+				"baserevid":      mediawiki.RevisionId("42"),
+				"starttimestamp": "2022-04-20T12:13:14Z",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.action.ToActionPayload(); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ToActionPayload() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestEdit_SetResponse(t *testing.T) {
+	tests := []struct {
+		name    string
+		json    string
+		want    Result
+		wantErr bool
+	}{
+		{
+			name:    "No edit",
+			json:    `{}`,
+			wantErr: true,
+		},
+		{
+			name:    "Stopped by an extension",
+			json:    `{"edit":{"result":"Failure","captcha":{"type":"image"}}}`,
+			wantErr: true,
+		},
+		{
+			name: "Saved",
+			json: `{"edit":{"result":"Success","pageid":7,"title":"Dummy Title","contentmodel":"wikitext","oldrevid":42,"newrevid":43,"newtimestamp":"2022-04-20T12:13:14Z"}}`,
+			want: Result{PageId: 7, Title: "Dummy Title", OldRevisionId: "42", NewRevisionId: "43"},
+		},
+		{
+			name: "No change",
+			json: `{"edit":{"result":"Success","pageid":7,"title":"Dummy Title","contentmodel":"wikitext","nochange":""}}`,
+			want: Result{PageId: 7, Title: "Dummy Title", IsNoChange: true},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var payload map[string]interface{}
+			if err := json.Unmarshal([]byte(tt.json), &payload); err != nil {
+				t.Fatal(err)
+			}
+
+			a := &Edit{Title: "Dummy Title"}
+			err := a.SetResponse(payload)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("SetResponse() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+
+			if got := a.GetResult(); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("GetResult() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
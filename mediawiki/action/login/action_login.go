@@ -0,0 +1,80 @@
+package login
+
+import (
+	"errors"
+	"fmt"
+)
+
+const actionName = "login"
+const resultSuccess = "Success"
+
+// Login starts a session with a bot password. The token is a login token from meta=tokens, requested in
+// the same session, i.e. with the same cookie jar.
+type Login struct {
+	// Name is the bot password user name, e.g. User@Bot
+	Name     string
+	Password string
+	Token    string
+
+	result Result
+}
+
+// Result is the account the session belongs to.
+type Result struct {
+	UserId   int
+	UserName string
+}
+
+func (Login) IsWriteAction() bool {
+	return false
+}
+
+func (a Login) Validate() error {
+	if a.Name == "" || a.Password == "" {
+		return errors.New("login requires a name and a password")
+	}
+
+	if a.Token == "" {
+		return errors.New("login requires a login token")
+	}
+
+	return nil
+}
+
+func (a Login) ToActionPayload() map[string]interface{} {
+	return map[string]interface{}{
+		"action":     actionName,
+		"lgname":     a.Name,
+		"lgpassword": a.Password,
+		"lgtoken":    a.Token,
+	}
+}
+
+func (a *Login) SetResponse(payload map[string]interface{}) error {
+	rawResult, ok := payload[actionName].(map[string]interface{})
+	if !ok {
+		return errors.New("response does not contain `login` or invalid structure")
+	}
+
+	status, _ := rawResult["result"].(string)
+	if status != resultSuccess {
+		// Wrong credentials are not reported as API errors
+		reason, _ := rawResult["reason"].(string)
+		return fmt.Errorf("login as %s failed, result: %s %s", a.Name, status, reason)
+	}
+
+	result := Result{}
+	result.UserName, _ = rawResult["lgusername"].(string)
+
+	if userId, ok := rawResult["lguserid"].(float64); ok {
+		result.UserId = int(userId)
+	}
+
+	a.result = result
+
+	return nil
+}
+
+func (a Login) GetResult() Result {
+	return a.result
+}
@@ -0,0 +1,69 @@
+package login
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+func TestLogin_Validate(t *testing.T) {
+	tests := []struct {
+		name    string
+		action  Login
+		wantErr bool
+	}{
+		{name: "Complete", action: Login{Name: "User@Bot", Password: "secret", Token: "token+\\"}},
+		{name: "No password", action: Login{Name: "User@Bot", Token: "token+\\"}, wantErr: true},
+		{name: "No token", action: Login{Name: "User@Bot", Password: "secret"}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.action.Validate(); (err != nil) != tt.wantErr {
+				t.Errorf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestLogin_SetResponse(t *testing.T) {
+	tests := []struct {
+		name     string
+		response string
+		want     Result
+		wantErr  bool
+	}{
+		{
+			name:     "Success",
+			response: `{"login": {"result": "Success", "lguserid": 42, "lgusername": "User"}}`,
+			want:     Result{UserId: 42, UserName: "User"},
+		},
+		{
+			name:     "Wrong password",
+			response: `{"login": {"result": "Failed", "reason": "Incorrect username or password entered."}}`,
+			wantErr:  true,
+		},
+		{
+			name:     "Invalid structure",
+			response: `{"query": {}}`,
+			wantErr:  true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var payload map[string]interface{}
+			_ = json.Unmarshal([]byte(tt.response), &payload)
+
+			action := &Login{Name: "User@Bot"}
+
+			if err := action.SetResponse(payload); (err != nil) != tt.wantErr {
+				t.Errorf("SetResponse() error = %v, wantErr %v", err, tt.wantErr)
+			}
+
+			if got := action.GetResult(); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("GetResult() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
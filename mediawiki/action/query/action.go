@@ -1,6 +1,16 @@
 package query
 
-import "golang.org/x/exp/maps"
+import (
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+const (
+	propModulesKey = "prop"
+	metaModulesKey = "meta"
+	listModulesKey = "list"
+)
 
 type Property interface {
 	ToPropertyPayload() map[string]interface{}
@@ -33,6 +43,19 @@ func (Query) IsWriteAction() bool {
 }
 
 func (a Query) ToActionPayload() map[string]interface{} {
+	payload, _ := a.buildPayload()
+
+	return payload
+}
+
+// Validate reports modules combined in one query that require different values of the same parameter.
+func (a Query) Validate() error {
+	_, err := a.buildPayload()
+
+	return err
+}
+
+func (a Query) buildPayload() (map[string]interface{}, error) {
 	payload := map[string]interface{}{
 		"action": "query",
 	}
@@ -45,22 +68,54 @@ func (a Query) ToActionPayload() map[string]interface{} {
 		payload["redirects"] = true
 	}
 
+	modules := make(map[string][]string, 3)
+	var err error
+
+	merge := func(modulesKey string, modulePayload map[string]interface{}) {
+		for k, v := range modulePayload {
+			if k == modulesKey {
+				modules[k] = appendModuleName(modules[k], v)
+				continue
+			}
+
+			if existing, ok := payload[k]; ok && !reflect.DeepEqual(existing, v) && err == nil {
+				err = fmt.Errorf("modules of the query clash on parameter %s: %v and %v", k, existing, v)
+				continue
+			}
+
+			payload[k] = v
+		}
+	}
+
 	for _, p := range a.Properties {
-		// FIXME: "prop" is overwritten, must be piped
-		maps.Copy(payload, p.ToPropertyPayload())
+		merge(propModulesKey, p.ToPropertyPayload())
 	}
 
 	for _, m := range a.Meta {
-		// FIXME: "meta" is overwritten, must be piped
-		maps.Copy(payload, m.ToMetaPayload())
+		merge(metaModulesKey, m.ToMetaPayload())
 	}
 
 	for _, l := range a.List {
-		// FIXME: "list" is overwritten, must be piped
-		maps.Copy(payload, l.ToListPayload())
+		merge(listModulesKey, l.ToListPayload())
 	}
 
-	return payload
+	for k, names := range modules {
+		payload[k] = strings.Join(names, "|")
+	}
+
+	return payload, err
+}
+
+func appendModuleName(names []string, name interface{}) []string {
+	nameStr := fmt.Sprint(name)
+
+	for _, existing := range names {
+		if existing == nameStr {
+			return names
+		}
+	}
+
+	return append(names, nameStr)
 }
 
 func (a Query) SetResponse(payload map[string]interface{}) error {
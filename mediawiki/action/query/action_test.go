@@ -84,6 +84,78 @@ func TestQuery_ToActionPayload(t *testing.T) {
 				"rctoponly": false,
 			},
 		},
+		{
+			name: "meta=tokens|userinfo",
+			query: Query{
+				Meta: []Meta{
+					&TokensMetaQuery{
+						Type: []string{"csrf"},
+					},
+					&UserinfoMetaQuery{
+						Properties: []string{"rights"},
+					},
+				},
+			},
+			want: map[string]interface{}{
+				"action": "query",
+				"meta":   "tokens|userinfo",
+				"type":   []string{"csrf"},
+				"uiprop": []string{"rights"},
+			},
+		},
+		{
+			name: "prop=revisions, list=recentchanges and meta=userinfo",
+			query: Query{
+				Properties: []Property{
+					&RevisionsQueryProperty{
+						Properties: []string{"ids"},
+						Limit:      1,
+					},
+				},
+				Meta: []Meta{
+					&UserinfoMetaQuery{},
+				},
+				List: []List{
+					&RecentChangesQueryList{
+						Start:     util.WithoutErr(time.Parse(time.RFC3339, "2022-04-20T12:13:14Z")),
+						Direction: "newer",
+						Limit:     10,
+					},
+				},
+				PageNames: []string{"Test"},
+			},
+			want: map[string]interface{}{
+				"action":    "query",
+				"titles":    []string{"Test"},
+				"prop":      "revisions",
+				"rvprop":    []string{"ids"},
+				"rvlimit":   1,
+				"meta":      "userinfo",
+				"uiprop":    util.CreateNilSlice[string](),
+				"list":      "recentchanges",
+				"rcstart":   "2022-04-20T12:13:14Z",
+				"rcdir":     "newer",
+				"rcshow":    util.CreateNilSlice[string](),
+				"rclimit":   10,
+				"rcprop":    util.CreateNilSlice[string](),
+				"rctype":    util.CreateNilSlice[string](),
+				"rctoponly": false,
+			},
+		},
+		{
+			name: "Same module twice is piped once",
+			query: Query{
+				Meta: []Meta{
+					&TokensMetaQuery{Type: []string{"csrf"}},
+					&TokensMetaQuery{Type: []string{"csrf"}},
+				},
+			},
+			want: map[string]interface{}{
+				"action": "query",
+				"meta":   "tokens",
+				"type":   []string{"csrf"},
+			},
+		},
 	}
 
 	for _, tt := range tests {
@@ -95,6 +167,102 @@ func TestQuery_ToActionPayload(t *testing.T) {
 	}
 }
 
+func TestQuery_Validate(t *testing.T) {
+	tests := []struct {
+		name    string
+		query   Query
+		wantErr bool
+	}{
+		{
+			name: "Different modules",
+			query: Query{
+				Meta: []Meta{
+					&TokensMetaQuery{Type: []string{"csrf"}},
+					&UserinfoMetaQuery{Properties: []string{"rights"}},
+				},
+			},
+		},
+		{
+			name: "Same module parameters agree",
+			query: Query{
+				Meta: []Meta{
+					&TokensMetaQuery{Type: []string{"csrf"}},
+					&TokensMetaQuery{Type: []string{"csrf"}},
+				},
+			},
+		},
+		{
+			name: "Same module parameters clash",
+			query: Query{
+				Properties: []Property{
+					&RevisionsQueryProperty{Properties: []string{"ids"}, Limit: 1},
+					&RevisionsQueryProperty{Properties: []string{"ids", "content"}, Limit: 1},
+				},
+			},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.query.Validate(); (err != nil) != tt.wantErr {
+				t.Errorf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestQuery_SetResponse_mixedModules(t *testing.T) {
+	tokens := &TokensMetaQuery{Type: []string{"csrf"}}
+	userinfo := &UserinfoMetaQuery{Properties: []string{"rights"}}
+	revisions := &RevisionsQueryProperty{Properties: []string{"ids"}, Limit: 1}
+
+	q := Query{
+		Properties: []Property{revisions},
+		Meta:       []Meta{tokens, userinfo},
+		PageNames:  []string{"Dummy Title"},
+	}
+
+	err := q.SetResponse(map[string]interface{}{
+		"batchcomplete": "",
+		"query": map[string]interface{}{
+			"pages": map[string]interface{}{
+				"42": map[string]interface{}{
+					"pageid": float64(42),
+					"title":  "Dummy Title",
+					"revisions": []interface{}{
+						map[string]interface{}{"revid": float64(1337)},
+					},
+				},
+			},
+			"tokens": map[string]interface{}{
+				"csrftoken": "tokenvalue",
+			},
+			"userinfo": map[string]interface{}{
+				"id":     float64(42),
+				"name":   "Bobby Tables",
+				"rights": []interface{}{"suppressrevision"},
+			},
+		},
+	})
+	if err != nil {
+		t.Fatalf("SetResponse() error = %v", err)
+	}
+
+	if got := tokens.GetTokens().Csrf; got != "tokenvalue" {
+		t.Errorf("GetTokens().Csrf = %v, want tokenvalue", got)
+	}
+
+	wantUserinfo := Userinfo{Id: 42, Name: "Bobby Tables", Rights: []string{"suppressrevision"}}
+	if got := userinfo.GetUserinfo(); !reflect.DeepEqual(got, wantUserinfo) {
+		t.Errorf("GetUserinfo() = %v, want %v", got, wantUserinfo)
+	}
+
+	if got := revisions.GetRevisions(); len(got) != 1 || got[0].Id != "1337" {
+		t.Errorf("GetRevisions() = %v, want revision 1337", got)
+	}
+}
+
 type mockProperty struct {
 	throwError  bool
 	responseSet bool
@@ -344,14 +512,20 @@ func TestRevisionsQueryProperty_GetRevisions(t *testing.T) {
 			payload: map[string]interface{}{
 				"pages": map[string]interface{}{
 					"42": map[string]interface{}{
-						"pageid": 42,
+						"pageid": float64(42),
 						"ns":     0,
 						"title":  "Dummy Title",
 						"revisions": []interface{}{ // Comes like that from json.Unmarshal()
 							map[string]interface{}{
 								"revid":     float64(1337), // Comes like that from json.Unmarshal()
-								"parentid":  73,
+								"parentid":  float64(73),
 								"timestamp": "2022-04-20T12:13:14Z",
+								"user":      "Bobby Tables",
+								"userid":    float64(7),
+								"comment":   "Dummy comment",
+								"sha1":      "da39a3ee5e6b4b0d3255bfef95601890afd80709",
+								"size":      float64(12),
+								"tags":      []interface{}{"mobile edit"},
 							},
 							map[string]interface{}{
 								"revid":      float64(73),
@@ -366,12 +540,24 @@ func TestRevisionsQueryProperty_GetRevisions(t *testing.T) {
 			},
 			want: []mediawiki.Revision{
 				{
-					Id:           "1337",
-					IsSuppressed: false,
+					Id:        "1337",
+					ParentId:  "73",
+					PageId:    42,
+					Title:     "Dummy Title",
+					User:      "Bobby Tables",
+					UserId:    7,
+					Comment:   "Dummy comment",
+					Sha1:      "da39a3ee5e6b4b0d3255bfef95601890afd80709",
+					Size:      12,
+					Tags:      []string{"mobile edit"},
+					Timestamp: util.WithoutErr(time.Parse(time.RFC3339, "2022-04-20T12:13:14Z")),
 				},
 				{
-					Id:           "73",
-					IsSuppressed: true,
+					Id:         "73",
+					Visibility: mediawiki.VisibilityUserHidden | mediawiki.VisibilitySuppressed,
+					PageId:     42,
+					Title:      "Dummy Title",
+					Timestamp:  util.WithoutErr(time.Parse(time.RFC3339, "2022-04-20T12:13:14Z")),
 				},
 			},
 			wantErr: false,
@@ -386,8 +572,9 @@ func TestRevisionsQueryProperty_GetRevisions(t *testing.T) {
 						"title":  "Dummy Title",
 						"revisions": []interface{}{ // Comes like that from json.Unmarshal()
 							map[string]interface{}{
-								"revid": float64(1337), // Comes like that from json.Unmarshal()
-								"*":     "page contents",
+								"revid":        float64(1337), // Comes like that from json.Unmarshal()
+								"contentmodel": "json",
+								"*":            "{}",
 							},
 						},
 					},
@@ -395,8 +582,10 @@ func TestRevisionsQueryProperty_GetRevisions(t *testing.T) {
 			},
 			want: []mediawiki.Revision{
 				{
-					Id:      "1337",
-					Content: "page contents",
+					Id:           "1337",
+					Title:        "Dummy Title",
+					Content:      "{}",
+					ContentModel: "json",
 				},
 			},
 		},
@@ -419,18 +608,90 @@ func TestRevisionsQueryProperty_GetRevisions(t *testing.T) {
 	}
 }
 
-func TestTokensQueryMeta_GetTokens(t *testing.T) {
-	type fields struct {
-		Type   []string
-		tokens struct {
-			Csrf string
-		}
+func TestRevisionsQueryProperty_GetPages(t *testing.T) {
+	payload := map[string]interface{}{
+		"normalized": []interface{}{
+			map[string]interface{}{"from": "dummy_title", "to": "Dummy title"},
+		},
+		"redirects": []interface{}{
+			map[string]interface{}{"from": "Dummy title", "to": "Target"},
+		},
+		"pages": map[string]interface{}{
+			"42": map[string]interface{}{
+				"pageid": float64(42),
+				"ns":     float64(0),
+				"title":  "Target",
+				"revisions": []interface{}{
+					map[string]interface{}{"revid": float64(1337)},
+				},
+			},
+			"43": map[string]interface{}{
+				"pageid": float64(43),
+				"ns":     float64(4),
+				"title":  "Project:Other",
+				"revisions": []interface{}{
+					map[string]interface{}{"revid": float64(1400)},
+				},
+			},
+			"-1": map[string]interface{}{
+				"ns":      float64(0),
+				"title":   "Missing",
+				"missing": "",
+			},
+			"-2": map[string]interface{}{
+				"title":         "Invalid[]",
+				"invalidreason": "The requested page title contains invalid characters.",
+				"invalid":       "",
+			},
+		},
+	}
+
+	qp := RevisionsQueryProperty{}
+	if err := qp.setResponse(payload); err != nil {
+		t.Fatalf("setResponse() error = %v", err)
+	}
+
+	wantPages := []mediawiki.Page{
+		{Title: "Invalid[]", IsInvalid: true},
+		{Title: "Missing", IsMissing: true},
+		{Id: 43, Namespace: 4, Title: "Project:Other", Revisions: []mediawiki.Revision{{Id: "1400", PageId: 43, Namespace: 4, Title: "Project:Other"}}},
+		{Id: 42, Title: "Target", Revisions: []mediawiki.Revision{{Id: "1337", PageId: 42, Title: "Target"}}},
+	}
+	if got := qp.GetPages(); !reflect.DeepEqual(got, wantPages) {
+		t.Errorf("GetPages() = %v, want %v", got, wantPages)
+	}
+
+	wantRevisions := []mediawiki.Revision{
+		{Id: "1400", PageId: 43, Namespace: 4, Title: "Project:Other"},
+		{Id: "1337", PageId: 42, Title: "Target"},
+	}
+	if got := qp.GetRevisions(); !reflect.DeepEqual(got, wantRevisions) {
+		t.Errorf("GetRevisions() = %v, want %v", got, wantRevisions)
+	}
+
+	if got := qp.ResolveTitle("dummy_title"); got != "Target" {
+		t.Errorf("ResolveTitle() = %v, want Target", got)
+	}
+
+	if page, ok := qp.GetPage("dummy_title"); !ok || page.Id != 42 {
+		t.Errorf("GetPage() = %v, %v, want page 42", page, ok)
+	}
+
+	if page, ok := qp.GetPage("Missing"); !ok || !page.IsMissing {
+		t.Errorf("GetPage() = %v, %v, want a missing page", page, ok)
 	}
+
+	if _, ok := qp.GetPage("Unknown"); ok {
+		t.Errorf("GetPage() must not find a title that was not requested")
+	}
+}
+
+func TestTokensQueryMeta_GetTokens(t *testing.T) {
 	tests := []struct {
 		name    string
 		types   []string
 		payload map[string]interface{}
-		want    struct{ Csrf string }
+		want    Tokens
 		wantErr bool
 	}{
 		{
@@ -441,13 +702,30 @@ func TestTokensQueryMeta_GetTokens(t *testing.T) {
 					"csrftoken": interface{}("tokenvalue"),
 				},
 			},
-			want: struct {
-				Csrf string
-			}{
-				Csrf: "tokenvalue",
-			},
+			want:    Tokens{Csrf: "tokenvalue"},
 			wantErr: false,
 		},
+		{
+			name:  "Login",
+			types: []string{"login"},
+			payload: map[string]interface{}{
+				"tokens": map[string]interface{}{
+					"logintoken": interface{}("logintokenvalue"),
+				},
+			},
+			want: Tokens{Login: "logintokenvalue"},
+		},
+		{
+			name:  "Watch and patrol",
+			types: []string{"watch", "patrol"},
+			payload: map[string]interface{}{
+				"tokens": map[string]interface{}{
+					"watchtoken":  interface{}("watchtokenvalue"),
+					"patroltoken": interface{}("patroltokenvalue"),
+				},
+			},
+			want: Tokens{Watch: "watchtokenvalue", Patrol: "patroltokenvalue"},
+		},
 	}
 
 	for _, tt := range tests {
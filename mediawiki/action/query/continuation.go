@@ -0,0 +1,122 @@
+package query
+
+import (
+	"freedom-sentry/mediawiki"
+	"golang.org/x/exp/maps"
+)
+
+const continueKey = "continue"
+
+// Iterator executes a Query batch by batch, following the API continuation protocol.
+//
+// Modules of the query hold the results of the latest batch only, so callers are expected to
+// collect them after every successful Next call:
+//
+//	it := query.NewIterator(api, q)
+//	for it.Next() {
+//		revs = append(revs, revProp.GetRevisions()...)
+//	}
+//	err := it.Err()
+type Iterator struct {
+	api   mediawiki.Api
+	query Query
+
+	continuation map[string]interface{}
+	done         bool
+	err          error
+}
+
+func NewIterator(api mediawiki.Api, q Query) *Iterator {
+	return &Iterator{
+		api:   api,
+		query: q,
+	}
+}
+
+// Next executes the next batch of the query. It returns false when the previous batch was the last one
+// or when the API returned an error, which is then available through Err.
+func (it *Iterator) Next() bool {
+	if it.done || it.err != nil {
+		return false
+	}
+
+	// Unless the response carries a continuation, this batch is the last one
+	it.done = true
+
+	err := it.api.Execute(&continuedQuery{iterator: it})
+	if err != nil {
+		it.err = err
+		return false
+	}
+
+	return true
+}
+
+func (it *Iterator) Err() error {
+	return it.err
+}
+
+// Continuation returns the parameters required to request the batch following the last executed one.
+// It is empty once the query is complete.
+func (it *Iterator) Continuation() map[string]interface{} {
+	if it.done {
+		return nil
+	}
+
+	return maps.Clone(it.continuation)
+}
+
+// ExecuteAll runs every batch of the query, calling collect after each of them.
+func ExecuteAll(api mediawiki.Api, q Query, collect func() error) error {
+	it := NewIterator(api, q)
+
+	for it.Next() {
+		if collect == nil {
+			continue
+		}
+
+		err := collect()
+		if err != nil {
+			return err
+		}
+	}
+
+	return it.Err()
+}
+
+type continuedQuery struct {
+	iterator *Iterator
+}
+
+func (c continuedQuery) IsWriteAction() bool {
+	return c.iterator.query.IsWriteAction()
+}
+
+func (c continuedQuery) Validate() error {
+	return c.iterator.query.Validate()
+}
+
+func (c continuedQuery) ToActionPayload() map[string]interface{} {
+	payload := c.iterator.query.ToActionPayload()
+
+	maps.Copy(payload, c.iterator.continuation)
+
+	return payload
+}
+
+func (c *continuedQuery) SetResponse(payload map[string]interface{}) error {
+	err := c.iterator.query.SetResponse(payload)
+	if err != nil {
+		return err
+	}
+
+	continuation, ok := payload[continueKey].(map[string]interface{})
+	if !ok || len(continuation) == 0 {
+		return nil
+	}
+
+	c.iterator.continuation = continuation
+	c.iterator.done = false
+
+	return nil
+}
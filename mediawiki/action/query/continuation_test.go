@@ -0,0 +1,160 @@
+package query
+
+import (
+	"errors"
+	"freedom-sentry/mediawiki"
+	"reflect"
+	"testing"
+)
+
+type mockApi struct {
+	responses []map[string]interface{}
+	payloads  []map[string]interface{}
+	failAt    int
+}
+
+func (m *mockApi) Execute(action mediawiki.Action) error {
+	m.payloads = append(m.payloads, action.ToActionPayload())
+
+	call := len(m.payloads)
+	if call == m.failAt {
+		return errors.New("dummy error")
+	}
+
+	return action.SetResponse(m.responses[call-1])
+}
+
+func revisionsBatch(continuation map[string]interface{}, revids ...float64) map[string]interface{} {
+	revs := make([]interface{}, len(revids))
+	for i, revid := range revids {
+		revs[i] = map[string]interface{}{"revid": revid}
+	}
+
+	batch := map[string]interface{}{
+		"query": map[string]interface{}{
+			"pages": map[string]interface{}{
+				"42": map[string]interface{}{
+					"pageid":    float64(42),
+					"title":     "Dummy Title",
+					"revisions": revs,
+				},
+			},
+		},
+	}
+
+	if continuation != nil {
+		batch["continue"] = continuation
+	}
+
+	return batch
+}
+
+func TestExecuteAll(t *testing.T) {
+	tests := []struct {
+		name              string
+		responses         []map[string]interface{}
+		failAt            int
+		wantRevisions     []mediawiki.RevisionId
+		wantContinuations []interface{}
+		wantErr           bool
+	}{
+		{
+			name: "Single batch",
+			responses: []map[string]interface{}{
+				revisionsBatch(nil, 1, 2),
+			},
+			wantRevisions:     []mediawiki.RevisionId{"1", "2"},
+			wantContinuations: []interface{}{nil},
+		},
+		{
+			name: "Three batches are merged",
+			responses: []map[string]interface{}{
+				revisionsBatch(map[string]interface{}{"rvcontinue": "20220420121314|3", "continue": "||"}, 1, 2),
+				revisionsBatch(map[string]interface{}{"rvcontinue": "20220420121314|5", "continue": "||"}, 3, 4),
+				revisionsBatch(nil, 5),
+			},
+			wantRevisions:     []mediawiki.RevisionId{"1", "2", "3", "4", "5"},
+			wantContinuations: []interface{}{nil, "20220420121314|3", "20220420121314|5"},
+		},
+		{
+			name: "Stops on error",
+			responses: []map[string]interface{}{
+				revisionsBatch(map[string]interface{}{"rvcontinue": "20220420121314|3", "continue": "||"}, 1, 2),
+				revisionsBatch(map[string]interface{}{"rvcontinue": "20220420121314|5", "continue": "||"}, 3, 4),
+				revisionsBatch(nil, 5),
+			},
+			failAt:            2,
+			wantRevisions:     []mediawiki.RevisionId{"1", "2"},
+			wantContinuations: []interface{}{nil, "20220420121314|3"},
+			wantErr:           true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			api := &mockApi{responses: tt.responses, failAt: tt.failAt}
+			revProp := &RevisionsQueryProperty{Properties: []string{"ids"}, Limit: 2}
+			q := Query{
+				Properties: []Property{revProp},
+				PageNames:  []string{"Dummy Title"},
+			}
+
+			var got []mediawiki.RevisionId
+			err := ExecuteAll(api, q, func() error {
+				for _, rev := range revProp.GetRevisions() {
+					got = append(got, rev.Id)
+				}
+				return nil
+			})
+
+			if (err != nil) != tt.wantErr {
+				t.Errorf("ExecuteAll() error = %v, wantErr %v", err, tt.wantErr)
+			}
+
+			if !reflect.DeepEqual(got, tt.wantRevisions) {
+				t.Errorf("ExecuteAll() collected %v, want %v", got, tt.wantRevisions)
+			}
+
+			continuations := make([]interface{}, len(api.payloads))
+			for i, payload := range api.payloads {
+				continuations[i] = payload["rvcontinue"]
+			}
+
+			if !reflect.DeepEqual(continuations, tt.wantContinuations) {
+				t.Errorf("ExecuteAll() sent continuations %v, want %v", continuations, tt.wantContinuations)
+			}
+		})
+	}
+}
+
+func TestIterator_Continuation(t *testing.T) {
+	api := &mockApi{
+		responses: []map[string]interface{}{
+			revisionsBatch(map[string]interface{}{"rvcontinue": "20220420121314|3", "continue": "||"}, 1, 2),
+			revisionsBatch(nil, 3),
+		},
+	}
+
+	it := NewIterator(api, Query{Properties: []Property{&RevisionsQueryProperty{}}})
+
+	if !it.Next() {
+		t.Fatalf("Next() must execute the first batch, error = %v", it.Err())
+	}
+
+	want := map[string]interface{}{"rvcontinue": "20220420121314|3", "continue": "||"}
+	if got := it.Continuation(); !reflect.DeepEqual(got, want) {
+		t.Errorf("Continuation() = %v, want %v", got, want)
+	}
+
+	if !it.Next() {
+		t.Fatalf("Next() must execute the second batch, error = %v", it.Err())
+	}
+
+	if got := it.Continuation(); got != nil {
+		t.Errorf("Continuation() = %v, want nil after the last batch", got)
+	}
+
+	if it.Next() {
+		t.Errorf("Next() must not execute past the last batch")
+	}
+}
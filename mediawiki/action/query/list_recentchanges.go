@@ -2,6 +2,7 @@ package query
 
 import (
 	"errors"
+	"fmt"
 	"freedom-sentry/mediawiki"
 	"time"
 )
@@ -14,12 +15,30 @@ type RecentChangesQueryList struct {
 	Limit      int
 	Types      []string
 	TopOnly    bool
+	// Continue resumes the list at the given change, see RecentChangesContinue
+	Continue string
 
 	recentChanges []mediawiki.Revision
 }
 
+const recentChangesContinueKey = "rccontinue"
+
+// RecentChangesContinue returns the continuation token of the change with the given rcid. The list started from it
+// includes the change itself.
+func RecentChangesContinue(timestamp time.Time, id mediawiki.RecentChangeId) string {
+	return fmt.Sprintf("%s|%d", timestamp.UTC().Format("20060102150405"), id)
+}
+
+// RecentChangesContinueOf returns the token of the change the list continues from, as found in the continuation
+// of an Iterator. It is empty once the list is complete.
+func RecentChangesContinueOf(continuation map[string]interface{}) string {
+	token, _ := continuation[recentChangesContinueKey].(string)
+
+	return token
+}
+
 func (r RecentChangesQueryList) ToListPayload() map[string]interface{} {
-	return map[string]interface{}{
+	payload := map[string]interface{}{
 		"list":      "recentchanges",
 		"rcstart":   r.Start.Format(time.RFC3339),
 		"rcdir":     r.Direction,
@@ -29,6 +48,12 @@ func (r RecentChangesQueryList) ToListPayload() map[string]interface{} {
 		"rctype":    r.Types,
 		"rctoponly": r.TopOnly,
 	}
+
+	if r.Continue != "" {
+		payload[recentChangesContinueKey] = r.Continue
+	}
+
+	return payload
 }
 
 func (r *RecentChangesQueryList) setResponse(json map[string]interface{}) error {
@@ -40,20 +65,35 @@ func (r *RecentChangesQueryList) setResponse(json map[string]interface{}) error
 	revs := make([]mediawiki.Revision, len(rawRevs))
 
 	for i, rawRev := range rawRevs {
-		rev := mediawiki.Revision{
-			Id: mediawiki.RevisionIdFromAny(rawRev["revid"]),
+		rev := parseRevision(rawRev)
+
+		rev.Title, _ = rawRev["title"].(string)
+
+		if oldRevId, ok := rawRev["old_revid"].(float64); ok {
+			rev.ParentId = mediawiki.RevisionIdFromAny(oldRevId)
 		}
 
-		_, rev.IsSuppressed = rawRev["suppressed"]
+		if pageId, ok := rawRev["pageid"].(float64); ok {
+			rev.PageId = mediawiki.PageId(pageId)
+		}
 
-		timestampStr, ok := rawRev["timestamp"].(string)
-		if ok {
-			if timestamp, err := time.Parse(time.RFC3339, timestampStr); err == nil {
-				rev.Timestamp = timestamp
-			}
+		if ns, ok := rawRev["ns"].(float64); ok {
+			rev.Namespace = int(ns)
 		}
 
-		rev.Title, _ = rawRev["title"].(string)
+		if rcId, ok := rawRev["rcid"].(float64); ok {
+			rev.RcId = mediawiki.RecentChangeId(rcId)
+		}
+
+		if size, ok := rawRev["newlen"].(float64); ok {
+			rev.Size = int(size)
+		}
+
+		if rcType, _ := rawRev["type"].(string); rcType == "log" {
+			// Log entries carry zero revision ids
+			rev.Id, rev.ParentId = "", ""
+			rev.Log = parseLogEvent(rawRev)
+		}
 
 		revs[i] = rev
 	}
@@ -63,6 +103,23 @@ func (r *RecentChangesQueryList) setResponse(json map[string]interface{}) error
 	return nil
 }
 
+func parseLogEvent(rawRev map[string]interface{}) *mediawiki.LogEvent {
+	event := &mediawiki.LogEvent{}
+	event.Type, _ = rawRev["logtype"].(string)
+	event.Action, _ = rawRev["logaction"].(string)
+
+	params, _ := rawRev["logparams"].(map[string]interface{})
+
+	switch event.Type {
+	case mediawiki.LogTypeMove:
+		event.TargetTitle, _ = params["target_title"].(string)
+	case mediawiki.LogTypeMerge:
+		event.TargetTitle, _ = params["dest_title"].(string)
+	}
+
+	return event
+}
+
 func jsonMapValueToSliceOfMaps(json interface{}) ([]map[string]interface{}, bool) {
 	rawSlice, ok := json.([]interface{})
 	if !ok {
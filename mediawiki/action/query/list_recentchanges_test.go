@@ -3,7 +3,6 @@ package query
 import (
 	"freedom-sentry/mediawiki"
 	"freedom-sentry/util"
-	"golang.org/x/exp/slices"
 	"reflect"
 	"testing"
 	"time"
@@ -37,6 +36,25 @@ func TestRecentChangesQueryList_ToListPayload(t *testing.T) {
 				"rctoponly": true,
 			},
 		},
+		{
+			name: "Continued",
+			list: RecentChangesQueryList{
+				Start:     util.WithoutErr(time.Parse(time.RFC3339, "2022-04-20T12:13:14Z")),
+				Direction: "newer",
+				Continue:  RecentChangesContinue(util.WithoutErr(time.Parse(time.RFC3339, "2022-04-20T14:13:14+02:00")), 4242),
+			},
+			want: map[string]interface{}{
+				"list":       "recentchanges",
+				"rcstart":    "2022-04-20T12:13:14Z",
+				"rcdir":      "newer",
+				"rcprop":     []string(nil),
+				"rcshow":     []string(nil),
+				"rclimit":    0,
+				"rctype":     []string(nil),
+				"rctoponly":  false,
+				"rccontinue": "20220420121314|4242",
+			},
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
@@ -71,6 +89,7 @@ func TestRecentChangesQueryList_setResponse(t *testing.T) {
 			json: map[string]interface{}{
 				"recentchanges": interface{}([]interface{}{}),
 			},
+			expected: []mediawiki.Revision{},
 		},
 		{
 			name: "Revision",
@@ -105,23 +124,126 @@ func TestRecentChangesQueryList_setResponse(t *testing.T) {
 						"revid":     float64(74),
 						"old_revid": float64(73),
 					},
+					map[string]interface{}{
+						"type":      "edit",
+						"ns":        float64(4),
+						"title":     "Project:Test title",
+						"pageid":    float64(43),
+						"revid":     float64(75),
+						"old_revid": float64(70),
+						"rcid":      float64(1001),
+						"user":      "Bobby Tables",
+						"userid":    float64(7),
+						"comment":   "Dummy comment",
+						"sha1":      "da39a3ee5e6b4b0d3255bfef95601890afd80709",
+						"oldlen":    float64(10),
+						"newlen":    float64(12),
+						"tags":      []interface{}{"mobile edit", "visualeditor"},
+						"timestamp": "2022-04-20T12:13:15Z",
+					},
+				}),
+			},
+			expected: []mediawiki.Revision{
+				{
+					Id:         "73",
+					ParentId:   "72",
+					PageId:     42,
+					Visibility: mediawiki.VisibilitySuppressed,
+					Timestamp:  util.WithoutErr(time.Parse(time.RFC3339, "2022-04-20T12:13:14Z")),
+					Title:      "Test title",
+				},
+				{
+					Id:         "73",
+					ParentId:   "72",
+					PageId:     42,
+					Visibility: mediawiki.VisibilitySuppressed,
+					Title:      "Test title",
+				},
+				{
+					Id:       "74",
+					ParentId: "73",
+					PageId:   42,
+					Title:    "Test title",
+				},
+				{
+					Id:        "75",
+					ParentId:  "70",
+					PageId:    43,
+					Namespace: 4,
+					Title:     "Project:Test title",
+					User:      "Bobby Tables",
+					UserId:    7,
+					Comment:   "Dummy comment",
+					Sha1:      "da39a3ee5e6b4b0d3255bfef95601890afd80709",
+					Size:      12,
+					Tags:      []string{"mobile edit", "visualeditor"},
+					RcId:      1001,
+					Timestamp: util.WithoutErr(time.Parse(time.RFC3339, "2022-04-20T12:13:15Z")),
+				},
+			},
+		},
+		{
+			name: "Log entries",
+			json: map[string]interface{}{
+				"recentchanges": interface{}([]interface{}{
+					map[string]interface{}{
+						"type":      "log",
+						"ns":        float64(0),
+						"title":     "Old title",
+						"pageid":    float64(42),
+						"revid":     float64(0),
+						"old_revid": float64(0),
+						"rcid":      float64(1002),
+						"logtype":   "move",
+						"logaction": "move",
+						"logparams": map[string]interface{}{"target_ns": float64(0), "target_title": "New title"},
+						"timestamp": "2022-04-20T12:13:16Z",
+					},
+					map[string]interface{}{
+						"type":      "log",
+						"ns":        float64(0),
+						"title":     "Source title",
+						"revid":     float64(0),
+						"old_revid": float64(0),
+						"rcid":      float64(1003),
+						"logtype":   "merge",
+						"logaction": "merge",
+						"logparams": map[string]interface{}{"dest_ns": float64(0), "dest_title": "Destination title"},
+						"timestamp": "2022-04-20T12:13:17Z",
+					},
+					map[string]interface{}{
+						"type":      "log",
+						"ns":        float64(0),
+						"title":     "Deleted title",
+						"revid":     float64(0),
+						"old_revid": float64(0),
+						"rcid":      float64(1004),
+						"logtype":   "delete",
+						"logaction": "restore",
+						"logparams": []interface{}{},
+						"timestamp": "2022-04-20T12:13:18Z",
+					},
 				}),
 			},
 			expected: []mediawiki.Revision{
 				{
-					Id:           "73",
-					IsSuppressed: true,
-					Timestamp:    util.WithoutErr(time.Parse(time.RFC3339, "2022-04-20T12:13:14Z")),
-					Title:        "Test title",
+					PageId:    42,
+					Title:     "Old title",
+					RcId:      1002,
+					Log:       &mediawiki.LogEvent{Type: "move", Action: "move", TargetTitle: "New title"},
+					Timestamp: util.WithoutErr(time.Parse(time.RFC3339, "2022-04-20T12:13:16Z")),
 				},
 				{
-					Id:           "73",
-					IsSuppressed: true,
-					Title:        "Test title",
+					Title:     "Source title",
+					RcId:      1003,
+					Log:       &mediawiki.LogEvent{Type: "merge", Action: "merge", TargetTitle: "Destination title"},
+					Timestamp: util.WithoutErr(time.Parse(time.RFC3339, "2022-04-20T12:13:17Z")),
 				},
 				{
-					Id:    "74",
-					Title: "Test title",
+					Title:     "Deleted title",
+					RcId:      1004,
+					Log:       &mediawiki.LogEvent{Type: "delete", Action: "restore"},
+					Timestamp: util.WithoutErr(time.Parse(time.RFC3339, "2022-04-20T12:13:18Z")),
 				},
 			},
 		},
@@ -137,9 +259,21 @@ func TestRecentChangesQueryList_setResponse(t *testing.T) {
 				return
 			}
 
-			if !slices.Equal(r.GetRecentChanges(), tt.expected) {
+			if !reflect.DeepEqual(r.GetRecentChanges(), tt.expected) {
 				t.Errorf("GetRecentChanges() got = %v, wanted = %v", r.GetRecentChanges(), tt.expected)
 			}
 		})
 	}
 }
+
+func TestRecentChangesContinueOf(t *testing.T) {
+	continuation := map[string]interface{}{"rccontinue": "20220420121314|4242", "continue": "-||"}
+
+	if got := RecentChangesContinueOf(continuation); got != "20220420121314|4242" {
+		t.Errorf("RecentChangesContinueOf() = %v, want 20220420121314|4242", got)
+	}
+
+	if got := RecentChangesContinueOf(nil); got != "" {
+		t.Errorf("RecentChangesContinueOf() = %v, want empty once the list is complete", got)
+	}
+}
@@ -1,13 +1,38 @@
 package query
 
-type TokensMetaQuery struct {
-	// FIXME: Only supports CSRF token requests
+import (
+	"errors"
+	"freedom-sentry/mediawiki"
+)
+
+// Tokens are the tokens returned by meta=tokens, empty unless requested.
+type Tokens struct {
+	Csrf   string
+	Login  string
+	Watch  string
+	Patrol string
+}
+
+// Get returns the token of the type, empty if it was not requested.
+func (t Tokens) Get(tokenType mediawiki.TokenType) string {
+	switch tokenType {
+	case mediawiki.TokenTypeCsrf:
+		return t.Csrf
+	case mediawiki.TokenTypeLogin:
+		return t.Login
+	case mediawiki.TokenTypeWatch:
+		return t.Watch
+	case mediawiki.TokenTypePatrol:
+		return t.Patrol
+	default:
+		return ""
+	}
+}
 
+type TokensMetaQuery struct {
 	Type []string
 
-	tokens struct {
-		Csrf string
-	}
+	tokens Tokens
 }
 
 func (qm TokensMetaQuery) ToMetaPayload() map[string]interface{} {
@@ -17,14 +42,20 @@ func (qm TokensMetaQuery) ToMetaPayload() map[string]interface{} {
 	}
 }
 
-func (qm TokensMetaQuery) GetTokens() struct{ Csrf string } {
+func (qm TokensMetaQuery) GetTokens() Tokens {
 	return qm.tokens
 }
 
 func (qm *TokensMetaQuery) setResponse(json map[string]interface{}) error {
-	tokens := json["tokens"].(map[string]interface{})
+	tokens, ok := json["tokens"].(map[string]interface{})
+	if !ok {
+		return errors.New("response does not contain `tokens` or invalid structure")
+	}
 
-	qm.tokens.Csrf = tokens["csrftoken"].(string)
+	qm.tokens.Csrf, _ = tokens["csrftoken"].(string)
+	qm.tokens.Login, _ = tokens["logintoken"].(string)
+	qm.tokens.Watch, _ = tokens["watchtoken"].(string)
+	qm.tokens.Patrol, _ = tokens["patroltoken"].(string)
 
 	return nil
 }
@@ -1,5 +1,7 @@
 package query
 
+import "errors"
+
 type Userinfo struct {
 	Id     uint64
 	Name   string
@@ -25,10 +27,15 @@ func (u UserinfoMetaQuery) GetUserinfo() Userinfo {
 }
 
 func (u *UserinfoMetaQuery) setResponse(json map[string]interface{}) error {
-	userinfo := json["userinfo"].(map[string]interface{})
+	userinfo, ok := json["userinfo"].(map[string]interface{})
+	if !ok {
+		return errors.New("response does not contain `userinfo` or invalid structure")
+	}
 
-	u.userinfo.Id = uint64(userinfo["id"].(float64))
-	u.userinfo.Name = userinfo["name"].(string)
+	if id, ok := userinfo["id"].(float64); ok {
+		u.userinfo.Id = uint64(id)
+	}
+	u.userinfo.Name, _ = userinfo["name"].(string)
 	if v, ok := userinfo["rights"].([]interface{}); ok {
 		u.userinfo.Rights = make([]string, len(v))
 		for i, r := range v {
@@ -3,27 +3,89 @@ package query
 import (
 	"errors"
 	"freedom-sentry/mediawiki"
+	"golang.org/x/exp/slices"
 )
 
+// TitleMapping describes how the API rewrote a requested title, either by normalizing it or by following a redirect.
+type TitleMapping struct {
+	From string
+	To   string
+}
+
+// RevisionsQueryProperty requests revisions of the pages of the query.
+//
+// MediaWiki returns the full history (Limit and continuation) only when the query has a single page. With multiple
+// pages, only the latest revision of each page is returned.
 type RevisionsQueryProperty struct {
 	Properties []string
 	Limit      int
 
-	revisions []mediawiki.Revision
+	pages      []mediawiki.Page
+	normalized []TitleMapping
+	redirects  []TitleMapping
 }
 
 func (qp RevisionsQueryProperty) ToPropertyPayload() map[string]interface{} {
 	payload := map[string]interface{}{
-		"prop":    "revisions",
-		"rvprop":  qp.Properties,
-		"rvlimit": qp.Limit,
+		"prop":   "revisions",
+		"rvprop": qp.Properties,
+	}
+
+	if qp.Limit > 0 {
+		payload["rvlimit"] = qp.Limit
 	}
 
 	return payload
 }
 
+// GetRevisions returns the revisions of all pages with their titles set.
 func (qp RevisionsQueryProperty) GetRevisions() []mediawiki.Revision {
-	return qp.revisions
+	var revisions []mediawiki.Revision
+
+	for _, page := range qp.pages {
+		revisions = append(revisions, page.Revisions...)
+	}
+
+	return revisions
+}
+
+func (qp RevisionsQueryProperty) GetPages() []mediawiki.Page {
+	return qp.pages
+}
+
+// GetPage returns the page a requested title resolved to after normalization and redirects.
+func (qp RevisionsQueryProperty) GetPage(title string) (mediawiki.Page, bool) {
+	resolved := qp.ResolveTitle(title)
+
+	for _, page := range qp.pages {
+		if page.Title == resolved {
+			return page, true
+		}
+	}
+
+	return mediawiki.Page{}, false
+}
+
+// ResolveTitle applies the normalization and redirect mappings returned by the API to a requested title.
+func (qp RevisionsQueryProperty) ResolveTitle(title string) string {
+	for _, mappings := range [][]TitleMapping{qp.normalized, qp.redirects} {
+		for _, mapping := range mappings {
+			if mapping.From == title {
+				title = mapping.To
+				break
+			}
+		}
+	}
+
+	return title
+}
+
+func (qp RevisionsQueryProperty) GetNormalized() []TitleMapping {
+	return qp.normalized
+}
+
+func (qp RevisionsQueryProperty) GetRedirects() []TitleMapping {
+	return qp.redirects
 }
 
 func (qp *RevisionsQueryProperty) setResponse(payload map[string]interface{}) error {
@@ -33,29 +95,69 @@ func (qp *RevisionsQueryProperty) setResponse(payload map[string]interface{}) er
 		return invalidPayloadErr
 	}
 
-	for _, page := range payload["pages"].(map[string]interface{}) {
-		revs := page.(map[string]interface{})["revisions"].([]interface{})
-		qp.revisions = parsePagesPayload(revs)
+	rawPages := payload["pages"].(map[string]interface{})
 
-		break
+	qp.pages = make([]mediawiki.Page, 0, len(rawPages))
+	for _, rawPage := range rawPages {
+		qp.pages = append(qp.pages, parsePagePayload(rawPage.(map[string]interface{})))
 	}
 
+	slices.SortFunc(qp.pages, func(a, b mediawiki.Page) bool {
+		return a.Title < b.Title
+	})
+
+	qp.normalized = parseTitleMappings(payload["normalized"])
+	qp.redirects = parseTitleMappings(payload["redirects"])
+
 	return nil
 }
 
+func parsePagePayload(rawPage map[string]interface{}) mediawiki.Page {
+	page := mediawiki.Page{}
+
+	if id, ok := rawPage["pageid"].(float64); ok {
+		page.Id = mediawiki.PageId(id)
+	}
+
+	if ns, ok := rawPage["ns"].(float64); ok {
+		page.Namespace = int(ns)
+	}
+
+	page.Title, _ = rawPage["title"].(string)
+	_, page.IsMissing = rawPage["missing"]
+	_, page.IsInvalid = rawPage["invalid"]
+
+	if revs, ok := rawPage["revisions"].([]interface{}); ok {
+		page.Revisions = parsePagesPayload(revs)
+
+		for i := range page.Revisions {
+			page.Revisions[i].PageId = page.Id
+			page.Revisions[i].Namespace = page.Namespace
+			page.Revisions[i].Title = page.Title
+		}
+	}
+
+	return page
+}
+
 func parsePagesPayload(revs []interface{}) []mediawiki.Revision {
 	revisions := make([]mediawiki.Revision, len(revs))
 
 	for i, trev := range revs {
 		rev := trev.(map[string]interface{})
 
-		_, isSuppressed := rev["suppressed"]
+		revision := parseRevision(rev)
+
+		if parentId, ok := rev["parentid"].(float64); ok {
+			revision.ParentId = mediawiki.RevisionIdFromAny(parentId)
+		}
 
-		revision := mediawiki.Revision{
-			Id:           mediawiki.RevisionIdFromAny(rev["revid"]),
-			IsSuppressed: isSuppressed,
+		if size, ok := rev["size"].(float64); ok {
+			revision.Size = int(size)
 		}
 
+		revision.ContentModel, _ = rev["contentmodel"].(string)
+
 		if content, ok := rev["*"].(string); ok {
 			revision.Content = content
 		}
@@ -65,6 +167,24 @@ func parsePagesPayload(revs []interface{}) []mediawiki.Revision {
 	return revisions
 }
 
+func parseTitleMappings(json interface{}) []TitleMapping {
+	rawMappings, ok := jsonMapValueToSliceOfMaps(json)
+	if !ok {
+		return nil
+	}
+
+	mappings := make([]TitleMapping, 0, len(rawMappings))
+	for _, rawMapping := range rawMappings {
+		mapping := TitleMapping{}
+		mapping.From, _ = rawMapping["from"].(string)
+		mapping.To, _ = rawMapping["to"].(string)
+
+		mappings = append(mappings, mapping)
+	}
+
+	return mappings
+}
+
 func isValidRevisionsPayload(payload map[string]interface{}) bool {
 	if len(payload) == 0 {
 		return false
@@ -79,8 +199,23 @@ func isValidRevisionsPayload(payload map[string]interface{}) bool {
 		return false
 	}
 
-	for _, page := range pages {
-		revisions, ok := page.(map[string]interface{})["revisions"].([]interface{})
+	for _, rawPage := range pages {
+		page, ok := rawPage.(map[string]interface{})
+		if !ok {
+			return false
+		}
+
+		rawRevisions, hasRevisions := page["revisions"]
+		if !hasRevisions {
+			// Missing and invalid pages come without revisions, yet every page has a title
+			if _, ok := page["title"].(string); !ok {
+				return false
+			}
+
+			continue
+		}
+
+		revisions, ok := rawRevisions.([]interface{})
 		if !ok {
 			return false
 		}
@@ -90,8 +225,6 @@ func isValidRevisionsPayload(payload map[string]interface{}) bool {
 				return false
 			}
 		}
-
-		break // Only take the first page in case API returns more than one, which is unlikely
 	}
 
 	return true
@@ -0,0 +1,40 @@
+package query
+
+import (
+	"freedom-sentry/mediawiki"
+	"time"
+)
+
+// parseRevision reads the fields shared by revisions of prop=revisions and list=recentchanges.
+func parseRevision(raw map[string]interface{}) mediawiki.Revision {
+	rev := mediawiki.Revision{
+		Id: mediawiki.RevisionIdFromAny(raw["revid"]),
+	}
+
+	rev.Visibility = mediawiki.VisibilityFromFlags(raw)
+
+	if timestampStr, ok := raw["timestamp"].(string); ok {
+		if timestamp, err := time.Parse(time.RFC3339, timestampStr); err == nil {
+			rev.Timestamp = timestamp
+		}
+	}
+
+	rev.User, _ = raw["user"].(string)
+	rev.Comment, _ = raw["comment"].(string)
+	rev.Sha1, _ = raw["sha1"].(string)
+
+	if userId, ok := raw["userid"].(float64); ok {
+		rev.UserId = uint64(userId)
+	}
+
+	if tags, ok := raw["tags"].([]interface{}); ok {
+		rev.Tags = make([]string, 0, len(tags))
+		for _, tag := range tags {
+			if tagStr, ok := tag.(string); ok {
+				rev.Tags = append(rev.Tags, tagStr)
+			}
+		}
+	}
+
+	return rev
+}
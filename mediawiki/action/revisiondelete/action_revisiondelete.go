@@ -10,12 +10,16 @@ const actionName = "revisiondelete"
 type RevisionDelete struct {
 	// Type of revision deletion being performed
 	Type Type
+	// Title of the page the revisions belong to
+	Target string
 	// Identifiers for the revisions to be deleted
 	Revisions []mediawiki.RevisionId
 	// What to hide for each revision
 	HideDetails []string
 	// Whether to suppress data from administrators as well as others
 	Suppress mediawiki.TextBool
+
+	result Result
 }
 
 func (RevisionDelete) IsWriteAction() bool {
@@ -29,6 +33,10 @@ func (a RevisionDelete) ToActionPayload() map[string]interface{} {
 		"ids":    a.Revisions,
 	}
 
+	if a.Target != "" {
+		payload["target"] = a.Target
+	}
+
 	if len(a.HideDetails) > 0 {
 		payload["hide"] = a.HideDetails
 	}
@@ -40,8 +48,17 @@ func (a RevisionDelete) ToActionPayload() map[string]interface{} {
 	return payload
 }
 
-func (a RevisionDelete) SetResponse(payload map[string]interface{}) error {
-	// TODO: Provide a detailed response
+func (a *RevisionDelete) SetResponse(payload map[string]interface{}) error {
+	result, err := parseResult(payload)
+	if err != nil {
+		return err
+	}
+
+	a.result = result
 
 	return nil
 }
+
+func (a RevisionDelete) GetResult() Result {
+	return a.result
+}
@@ -1,6 +1,7 @@
 package revisiondelete
 
 import (
+	"encoding/json"
 	"freedom-sentry/mediawiki"
 	"freedom-sentry/util"
 	"reflect"
@@ -38,6 +39,24 @@ func TestRevisionDelete_ToActionPayload(t *testing.T) {
 				"suppress": mediawiki.TextBoolYes,
 			},
 		},
+		{
+			name: "Revisions of a target page",
+			action: RevisionDelete{
+				Type:        "revision",
+				Target:      "Dummy Title",
+				Revisions:   []mediawiki.RevisionId{"42"},
+				HideDetails: []string{"user"},
+				Suppress:    mediawiki.TextBoolYes,
+			},
+			want: map[string]interface{}{
+				"action":   actionName,
+				"type":     TypeRevision,
+				"target":   "Dummy Title",
+				"ids":      []mediawiki.RevisionId{"42"},
+				"hide":     []string{"user"},
+				"suppress": mediawiki.TextBoolYes,
+			},
+		},
 	}
 
 	for _, tt := range tests {
@@ -48,3 +67,74 @@ func TestRevisionDelete_ToActionPayload(t *testing.T) {
 		})
 	}
 }
+
+func TestRevisionDelete_SetResponse(t *testing.T) {
+	tests := []struct {
+		name    string
+		json    string
+		want    Result
+		wantErr bool
+	}{
+		{
+			name:    "No revisiondelete",
+			json:    `{}`,
+			wantErr: true,
+		},
+		{
+			name: "Partial failure",
+			json: `{"revisiondelete":{"status":"Success","target":"Dummy Title","items":[
+				{"status":"success","id":42,"timestamp":"2022-04-20T12:13:14Z","userhidden":"","commenthidden":"","suppressed":"","errors":[],"warnings":[]},
+				{"status":"fail","id":1337,"errors":[{"type":"error","message":"revdelete-modify-missing","params":["1337"]}],"warnings":[]}
+			]}}`,
+			want: Result{
+				Status: "Success",
+				Target: "Dummy Title",
+				Items: []ResultItem{
+					{
+						Id:         "42",
+						Status:     StatusSuccess,
+						Visibility: mediawiki.VisibilityUserHidden | mediawiki.VisibilityCommentHidden | mediawiki.VisibilitySuppressed,
+					},
+					{
+						Id:     "1337",
+						Status: StatusFail,
+						Errors: []string{"revdelete-modify-missing"},
+					},
+				},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var payload map[string]interface{}
+			if err := json.Unmarshal([]byte(tt.json), &payload); err != nil {
+				t.Fatal(err)
+			}
+
+			a := &RevisionDelete{}
+			err := a.SetResponse(payload)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("SetResponse() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+
+			if tt.wantErr {
+				return
+			}
+
+			if got := a.GetResult(); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("GetResult() = %+v, want %+v", got, tt.want)
+			}
+
+			if !a.GetResult().IsSuccess() {
+				t.Errorf("IsSuccess() must be true for the overall status")
+			}
+
+			failed := a.GetResult().Failed()
+			if len(failed) != 1 || failed[0].Id != "1337" {
+				t.Errorf("Failed() = %v, want the item 1337", failed)
+			}
+		})
+	}
+}
@@ -0,0 +1,106 @@
+package revisiondelete
+
+import (
+	"errors"
+	"freedom-sentry/mediawiki"
+	"strings"
+)
+
+const (
+	StatusSuccess = "success"
+	StatusFail    = "fail"
+)
+
+// Result is the outcome of a revisiondelete action.
+type Result struct {
+	// Status is the overall status, "Success" or "Fail"
+	Status string
+	Target string
+	Items  []ResultItem
+}
+
+// ResultItem is the outcome of hiding the details of a single revision.
+type ResultItem struct {
+	Id     mediawiki.RevisionId
+	Status string
+	// Errors holds the message keys of the errors preventing the change
+	Errors []string
+	// Visibility is the visibility of the revision after the action
+	Visibility mediawiki.Visibility
+}
+
+func (i ResultItem) IsSuccess() bool {
+	return i.Status == StatusSuccess
+}
+
+func (r Result) IsSuccess() bool {
+	return strings.EqualFold(r.Status, StatusSuccess)
+}
+
+// Failed returns the items that could not be changed.
+func (r Result) Failed() []ResultItem {
+	var failed []ResultItem
+
+	for _, item := range r.Items {
+		if !item.IsSuccess() {
+			failed = append(failed, item)
+		}
+	}
+
+	return failed
+}
+
+func parseResult(payload map[string]interface{}) (Result, error) {
+	rawResult, ok := payload[actionName].(map[string]interface{})
+	if !ok {
+		return Result{}, errors.New("response does not contain `revisiondelete` or invalid structure")
+	}
+
+	result := Result{}
+	result.Status, _ = rawResult["status"].(string)
+	result.Target, _ = rawResult["target"].(string)
+
+	rawItems, ok := rawResult["items"].([]interface{})
+	if !ok {
+		return result, nil
+	}
+
+	result.Items = make([]ResultItem, 0, len(rawItems))
+
+	for _, rawItem := range rawItems {
+		item, ok := rawItem.(map[string]interface{})
+		if !ok {
+			return result, errors.New("invalid `revisiondelete` item structure")
+		}
+
+		result.Items = append(result.Items, parseResultItem(item))
+	}
+
+	return result, nil
+}
+
+func parseResultItem(rawItem map[string]interface{}) ResultItem {
+	item := ResultItem{
+		Id:         mediawiki.RevisionIdFromAny(rawItem["id"]),
+		Visibility: mediawiki.VisibilityFromFlags(rawItem),
+	}
+
+	item.Status, _ = rawItem["status"].(string)
+
+	rawErrors, _ := rawItem["errors"].([]interface{})
+	for _, rawError := range rawErrors {
+		itemErr, ok := rawError.(map[string]interface{})
+		if !ok {
+			continue
+		}
+
+		message, ok := itemErr["message"].(string)
+		if !ok {
+			message, _ = itemErr["code"].(string)
+		}
+
+		item.Errors = append(item.Errors, message)
+	}
+
+	return item
+}
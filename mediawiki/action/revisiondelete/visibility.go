@@ -0,0 +1,52 @@
+package revisiondelete
+
+import "freedom-sentry/mediawiki"
+
+const (
+	HideContent = "content"
+	HideComment = "comment"
+	HideUser    = "user"
+)
+
+var hideDetailVisibility = map[string]mediawiki.Visibility{
+	HideContent: mediawiki.VisibilityTextHidden,
+	HideComment: mediawiki.VisibilityCommentHidden,
+	HideUser:    mediawiki.VisibilityUserHidden,
+}
+
+// VisibilityOf returns the visibility a revision ends up with once the details are hidden.
+func VisibilityOf(hideDetails []string, suppress mediawiki.TextBool) mediawiki.Visibility {
+	var v mediawiki.Visibility
+
+	for _, detail := range hideDetails {
+		v |= hideDetailVisibility[detail]
+	}
+
+	if suppress == mediawiki.TextBoolYes {
+		v |= mediawiki.VisibilitySuppressed
+	}
+
+	return v
+}
+
+// hideDetailOrder lists the details the way the API documents them
+var hideDetailOrder = []string{HideContent, HideComment, HideUser}
+
+func IsHideDetail(detail string) bool {
+	_, ok := hideDetailVisibility[detail]
+
+	return ok
+}
+
+// HideDetailsOf returns the details to hide for a revision to get the given visibility.
+func HideDetailsOf(v mediawiki.Visibility) []string {
+	var hideDetails []string
+
+	for _, detail := range hideDetailOrder {
+		if v.Has(hideDetailVisibility[detail]) {
+			hideDetails = append(hideDetails, detail)
+		}
+	}
+
+	return hideDetails
+}
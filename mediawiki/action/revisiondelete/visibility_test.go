@@ -0,0 +1,70 @@
+package revisiondelete
+
+import (
+	"freedom-sentry/mediawiki"
+	"reflect"
+	"testing"
+)
+
+func TestVisibilityOf(t *testing.T) {
+	tests := []struct {
+		name        string
+		hideDetails []string
+		suppress    mediawiki.TextBool
+		want        mediawiki.Visibility
+	}{
+		{
+			name: "Nothing",
+		},
+		{
+			name:        "Deleted user and comment",
+			hideDetails: []string{HideUser, HideComment},
+			suppress:    mediawiki.TextBoolNo,
+			want:        mediawiki.VisibilityUserHidden | mediawiki.VisibilityCommentHidden,
+		},
+		{
+			name:        "Suppressed everything",
+			hideDetails: []string{HideContent, HideUser, HideComment},
+			suppress:    mediawiki.TextBoolYes,
+			want:        mediawiki.VisibilityTextHidden | mediawiki.VisibilityUserHidden | mediawiki.VisibilityCommentHidden | mediawiki.VisibilitySuppressed,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := VisibilityOf(tt.hideDetails, tt.suppress); got != tt.want {
+				t.Errorf("VisibilityOf() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestHideDetailsOf(t *testing.T) {
+	tests := []struct {
+		name string
+		v    mediawiki.Visibility
+		want []string
+	}{
+		{
+			name: "Nothing",
+		},
+		{
+			name: "Suppression flag is not a detail",
+			v:    mediawiki.VisibilityUserHidden | mediawiki.VisibilityCommentHidden | mediawiki.VisibilitySuppressed,
+			want: []string{HideComment, HideUser},
+		},
+		{
+			name: "Everything",
+			v:    mediawiki.VisibilityTextHidden | mediawiki.VisibilityUserHidden | mediawiki.VisibilityCommentHidden,
+			want: []string{HideContent, HideComment, HideUser},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := HideDetailsOf(tt.v); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("HideDetailsOf() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
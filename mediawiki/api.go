@@ -1,5 +1,25 @@
 package mediawiki
 
+import (
+	gohttp "net/http"
+	"net/url"
+)
+
 type Api interface {
 	Execute(Action) error
 }
+
+// Authenticator authorizes requests to the API on behalf of the bot account.
+type Authenticator interface {
+	// Authenticate adds credentials to the request. The form holds the parameters of the request body, those
+	// added to it are sent too.
+	Authenticate(req *gohttp.Request, form url.Values) error
+}
+
+// RenewableAuthenticator is implemented by authenticators whose credentials expire, e.g. login sessions.
+type RenewableAuthenticator interface {
+	Authenticator
+	// Renew replaces the credentials once the API rejects them with ErrAssertUserFailed, the action is retried
+	// afterwards.
+	Renew() error
+}
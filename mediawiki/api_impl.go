@@ -2,15 +2,16 @@ package mediawiki
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
-	"freedom-sentry/config"
 	"freedom-sentry/http"
 	"freedom-sentry/util"
+	"golang.org/x/exp/slices"
+	"io"
 	"io/ioutil"
 	"log"
 	gohttp "net/http"
 	"net/url"
-	"os"
 	"reflect"
 	"strings"
 )
@@ -18,31 +19,107 @@ import (
 const userAgent = "FreedomSentry/1"
 const writeTokenKey = "token"
 
-type Token string
-type TokenRequestFn func(api Api) (Token, error)
+// secretKeys are the parameters never logged
+var secretKeys = []string{writeTokenKey, "lgpassword", "lgtoken"}
 
 type apiImpl struct {
-	httpClient http.Client
-	endpoint   string
-	tokenFn    TokenRequestFn
+	httpClient    http.Client
+	endpoint      string
+	authenticator Authenticator
+	tokens        *tokenCache
+	warningFn     WarningHandler
+
+	lag         *lagMonitor
+	maxLag      int
+	writeMaxLag int
 }
 
-func NewApi(endpoint string, client http.Client, tokenFn TokenRequestFn) Api {
-	return &apiImpl{
-		httpClient: client,
-		endpoint:   endpoint,
-		tokenFn:    tokenFn,
+func NewApi(endpoint string, client http.Client, authenticator Authenticator, tokenFn TokenRequestFn, opts ...util.Option[apiImpl]) Api {
+	api := &apiImpl{
+		httpClient:    client,
+		endpoint:      endpoint,
+		authenticator: authenticator,
+		tokens:        newTokenCache(tokenFn),
+		warningFn:     logWarnings,
+		lag:           newLagMonitor(),
 	}
+
+	util.ApplyOptions(api, opts...)
+
+	return api
 }
 
+// Execute sends the action. An action rejected because the session of a RenewableAuthenticator has expired is
+// sent once more after renewing it, and a write action rejected with ErrBadToken once more with a new token.
 func (api *apiImpl) Execute(action Action) error {
+	err := api.executeRespectingLag(action)
+
+	if renewable, isRenewable := api.authenticator.(RenewableAuthenticator); isRenewable && errors.Is(err, ErrAssertUserFailed) {
+		log.Println("the session has expired, logging in again:", err)
+
+		err = renewable.Renew()
+		if err != nil {
+			return err
+		}
+
+		api.tokens.invalidateAll()
+
+		err = api.executeRespectingLag(action)
+	}
+
+	if action.IsWriteAction() && errors.Is(err, ErrBadToken) {
+		log.Println("the token has been rejected, requesting a new one")
+
+		api.tokens.invalidate(tokenTypeOf(action))
+
+		err = api.executeRespectingLag(action)
+	}
+
+	return err
+}
+
+func (api *apiImpl) ReplicationLag() ReplicationLag {
+	return api.lag.get()
+}
+
+// executeRespectingLag waits with reads while the replicas are lagging, and sends them again if they are
+// rejected with ErrMaxLag. Writes are sent right away.
+func (api *apiImpl) executeRespectingLag(action Action) error {
+	if action.IsWriteAction() {
+		return api.execute(action)
+	}
+
+	for attempt := 1; ; attempt++ {
+		api.lag.waitForReads()
+
+		err := api.execute(action)
+		if !errors.Is(err, ErrMaxLag) || attempt == maxLagReadAttempts {
+			return err
+		}
+	}
+}
+
+func (api *apiImpl) execute(action Action) error {
+	if validating, ok := action.(ValidatingAction); ok {
+		if err := validating.Validate(); err != nil {
+			return err
+		}
+	}
+
 	payload := action.ToActionPayload()
 
-	log.Println("executing action", payload)
+	maxLag := api.maxLag
+	if action.IsWriteAction() {
+		maxLag = api.writeMaxLag
+	}
+	if maxLag > 0 {
+		payload[maxLagKey] = maxLag
+	}
+
+	log.Println("executing action", redactPayload(payload))
 
 	if action.IsWriteAction() {
-		log.Println("injecting token for a write action")
-		err := api.injectToken(payload)
+		err := api.injectToken(payload, tokenTypeOf(action))
 		if err != nil {
 			return err
 		}
@@ -70,6 +147,18 @@ func (api *apiImpl) Execute(action Action) error {
 		return err
 	}
 
+	apiErr := parseApiError(respJson)
+
+	api.lag.observe(resp.Header, respJson, apiErr != nil && errors.Is(apiErr, ErrMaxLag))
+
+	if apiErr != nil {
+		return apiErr
+	}
+
+	if warnings := parseWarnings(respJson); len(warnings) > 0 && api.warningFn != nil {
+		api.warningFn(action, warnings)
+	}
+
 	err = action.SetResponse(respJson)
 
 	return err
@@ -79,20 +168,38 @@ func (api *apiImpl) createRequest(payload map[string]interface{}) (*gohttp.Reque
 	data := payloadToUrlValues(payload)
 	data.Set("format", "json")
 
-	request, err := gohttp.NewRequest(gohttp.MethodPost, api.endpoint, strings.NewReader(data.Encode()))
+	request, err := gohttp.NewRequest(gohttp.MethodPost, api.endpoint, nil)
 	if err != nil {
 		return nil, err
 	}
 
 	request.Header.Set("User-Agent", userAgent)
 	request.Header.Set("Content-Type", "application/x-www-form-urlencoded")
-	request.Header.Set("Authorization", "Bearer "+os.Getenv(config.EnvAccessToken))
 
-	return request, nil
+	err = api.authenticator.Authenticate(request, data)
+	if err != nil {
+		return nil, err
+	}
+
+	// The body is encoded last, so that it includes the parameters added by the authenticator
+	body := data.Encode()
+
+	request.ContentLength = int64(len(body))
+	request.Body = io.NopCloser(strings.NewReader(body))
+	request.GetBody = func() (io.ReadCloser, error) {
+		return io.NopCloser(strings.NewReader(body)), nil
+	}
+
+	// Retries are authenticated anew, as e.g. OAuth signatures must not reuse their nonce
+	retryHook := func(retry *gohttp.Request) error {
+		return api.authenticator.Authenticate(retry, data)
+	}
+
+	return request.WithContext(http.WithRetryHook(request.Context(), retryHook)), nil
 }
 
-func (api *apiImpl) injectToken(payload map[string]interface{}) error {
-	token, err := api.tokenFn(api)
+func (api *apiImpl) injectToken(payload map[string]interface{}, tokenType TokenType) error {
+	token, err := api.tokens.get(api, tokenType)
 	if err != nil {
 		return err
 	}
@@ -102,6 +209,21 @@ func (api *apiImpl) injectToken(payload map[string]interface{}) error {
 	return nil
 }
 
+// redactPayload hides passwords and tokens from the logged payload.
+func redactPayload(payload map[string]interface{}) map[string]interface{} {
+	redacted := make(map[string]interface{}, len(payload))
+
+	for k, v := range payload {
+		if slices.Contains(secretKeys, k) {
+			v = "[redacted]"
+		}
+
+		redacted[k] = v
+	}
+
+	return redacted
+}
+
 func payloadToUrlValues(payload map[string]interface{}) url.Values {
 	data := url.Values{}
 
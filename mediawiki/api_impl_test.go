@@ -2,7 +2,8 @@ package mediawiki
 
 import (
 	"errors"
-	"freedom-sentry/config"
+	"fmt"
+	sentryhttp "freedom-sentry/http"
 	"freedom-sentry/util"
 	"golang.org/x/exp/maps"
 	"golang.org/x/exp/slices"
@@ -10,8 +11,10 @@ import (
 	"io/ioutil"
 	"net/http"
 	"net/url"
+	"reflect"
 	"strings"
 	"testing"
+	"time"
 )
 
 type mockClient struct {
@@ -31,12 +34,25 @@ func (m *mockClient) Do(req *http.Request) (*http.Response, error) {
 	return m.response, err
 }
 
+type mockAuthenticator struct {
+	token string
+}
+
+func (m *mockAuthenticator) Authenticate(req *http.Request, _ url.Values) error {
+	req.Header.Set("Authorization", "Bearer "+m.token)
+
+	return nil
+}
+
 type mockTokenFn struct {
 	token      string
 	throwError bool
+	requests   []TokenType
 }
 
-func (t *mockTokenFn) tokenFn(Api) (Token, error) {
+func (t *mockTokenFn) tokenFn(_ Api, tokenType TokenType) (Token, error) {
+	t.requests = append(t.requests, tokenType)
+
 	if t.throwError {
 		return "", errors.New("dummy error")
 	}
@@ -106,8 +122,6 @@ const expectedToken = "expected-token"
 const expectedDestination = "https://example.org/"
 
 func Test_apiImpl_Execute(t *testing.T) {
-
-	t.Setenv(config.EnvAccessToken, expectedToken)
 	tests := []struct {
 		name            string
 		destination     string
@@ -227,7 +241,7 @@ func Test_apiImpl_Execute(t *testing.T) {
 				token:      tt.writeToken,
 				throwError: tt.wantTokenErr,
 			}
-			api := NewApi(tt.destination, client, tokenFn.tokenFn)
+			api := NewApi(tt.destination, client, &mockAuthenticator{token: expectedToken}, tokenFn.tokenFn)
 
 			if err := api.Execute(tt.action); (err != nil) != tt.wantErr {
 				t.Errorf("Execute() error = %v, wantErr %v", err, tt.wantErr)
@@ -251,3 +265,450 @@ func createBodyForValues(values map[string]string) io.ReadCloser {
 
 	return io.NopCloser(strings.NewReader(urlValues.Encode()))
 }
+
+func Test_apiImpl_Execute_errors(t *testing.T) {
+	tests := []struct {
+		name     string
+		response string
+		want     error
+		wantCode string
+	}{
+		{
+			name:     "Bad token",
+			response: `{"error":{"code":"badtoken","info":"Invalid CSRF token.","*":"See https://example.org/w/api.php for API usage."}}`,
+			want:     ErrBadToken,
+			wantCode: "badtoken",
+		},
+		{
+			name:     "Max lag",
+			response: `{"error":{"code":"maxlag","info":"Waiting for 10.64.48.35: 7 seconds lagged.","host":"10.64.48.35","lag":7}}`,
+			want:     ErrMaxLag,
+			wantCode: "maxlag",
+		},
+		{
+			name:     "Unknown code",
+			response: `{"error":{"code":"nosuchrevid","info":"There is no revision with ID 42.","module":"revisiondelete"}}`,
+			wantCode: "nosuchrevid",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Reads rejected because of the lag are sent again
+			client := &sequenceClient{}
+			for i := 0; i < maxLagReadAttempts; i++ {
+				client.responses = append(client.responses, tt.response)
+			}
+			action := &dummyAction{}
+			api := NewApi(expectedDestination, client, &mockAuthenticator{token: expectedToken}, (&mockTokenFn{}).tokenFn)
+			api.(*apiImpl).lag.sleep = func(time.Duration) {}
+
+			err := api.Execute(action)
+
+			var apiErr *ApiError
+			if !errors.As(err, &apiErr) {
+				t.Fatalf("Execute() error = %v, want *ApiError", err)
+			}
+
+			if apiErr.Code != tt.wantCode {
+				t.Errorf("ApiError.Code = %v, want %v", apiErr.Code, tt.wantCode)
+			}
+
+			if tt.want != nil && !errors.Is(err, tt.want) {
+				t.Errorf("Execute() error = %v, must match %v", err, tt.want)
+			}
+
+			for _, sentinel := range []error{ErrBadToken, ErrRateLimited, ErrMaxLag, ErrPermissionDenied, ErrReadOnly} {
+				if sentinel != tt.want && errors.Is(err, sentinel) {
+					t.Errorf("Execute() error = %v, must not match %v", err, sentinel)
+				}
+			}
+
+			if action.response != nil {
+				t.Errorf("SetResponse() must not be called for an error response")
+			}
+		})
+	}
+}
+
+func Test_apiImpl_Execute_warnings(t *testing.T) {
+	client := &mockClient{
+		response: &http.Response{Body: io.NopCloser(strings.NewReader(
+			`{"warnings":{"revisions":{"*":"Limit exceeded"},"main":{"*":"Unrecognized parameter"}},"query":{}}`,
+		))},
+	}
+
+	var got []Warning
+	api := NewApi(expectedDestination, client, &mockAuthenticator{token: expectedToken}, (&mockTokenFn{}).tokenFn, WithWarningHandler(func(_ Action, warnings []Warning) {
+		got = warnings
+	}))
+
+	action := &dummyAction{}
+	if err := api.Execute(action); err != nil {
+		t.Fatalf("Execute() error = %v, warnings must not fail the action", err)
+	}
+
+	want := []Warning{
+		{Module: "main", Text: "Unrecognized parameter"},
+		{Module: "revisions", Text: "Limit exceeded"},
+	}
+	if !slices.Equal(got, want) {
+		t.Errorf("warning handler got %v, want %v", got, want)
+	}
+
+	if action.response == nil {
+		t.Errorf("SetResponse() must be called despite warnings")
+	}
+}
+
+type sequenceClient struct {
+	responses []string
+	// headers are those of the responses, if any
+	headers  []http.Header
+	requests int
+	forms    []url.Values
+}
+
+func (s *sequenceClient) Do(req *http.Request) (*http.Response, error) {
+	_ = req.ParseForm()
+	s.forms = append(s.forms, req.PostForm)
+
+	response := &http.Response{Body: io.NopCloser(strings.NewReader(s.responses[s.requests]))}
+	if s.requests < len(s.headers) {
+		response.Header = s.headers[s.requests]
+	}
+
+	s.requests++
+
+	return response, nil
+}
+
+type mockRenewableAuthenticator struct {
+	mockAuthenticator
+	renewals int
+}
+
+func (m *mockRenewableAuthenticator) Renew() error {
+	m.renewals++
+
+	return nil
+}
+
+func Test_apiImpl_Execute_renewsExpiredSession(t *testing.T) {
+	expired := `{"error":{"code":"assertnameduserfailed","info":"You are no longer logged in as \"User\"."}}`
+
+	tests := []struct {
+		name         string
+		responses    []string
+		wantErr      error
+		wantRenewals int
+	}{
+		{
+			name:         "Retried after renewing",
+			responses:    []string{expired, `{"test": 42}`},
+			wantRenewals: 1,
+		},
+		{
+			name:         "Renewed once",
+			responses:    []string{expired, expired},
+			wantErr:      ErrAssertUserFailed,
+			wantRenewals: 1,
+		},
+		{
+			name:      "Other errors are not retried",
+			responses: []string{`{"error":{"code":"readonly","info":"The wiki is in read-only mode."}}`},
+			wantErr:   ErrReadOnly,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := &sequenceClient{responses: tt.responses}
+			authenticator := &mockRenewableAuthenticator{}
+			api := NewApi(expectedDestination, client, authenticator, (&mockTokenFn{}).tokenFn)
+
+			err := api.Execute(&dummyAction{})
+			if !errors.Is(err, tt.wantErr) || (err == nil) != (tt.wantErr == nil) {
+				t.Errorf("Execute() error = %v, want %v", err, tt.wantErr)
+			}
+
+			if authenticator.renewals != tt.wantRenewals {
+				t.Errorf("Renew() called %d times, want %d", authenticator.renewals, tt.wantRenewals)
+			}
+
+			if client.requests != len(tt.responses) {
+				t.Errorf("sent %d requests, want %d", client.requests, len(tt.responses))
+			}
+		})
+	}
+}
+
+type watchAction struct {
+	dummyAction
+}
+
+func (watchAction) TokenType() TokenType {
+	return TokenTypeWatch
+}
+
+func newWriteAction() *dummyAction {
+	return &dummyAction{isWrite: true, payload: map[string]interface{}{}}
+}
+
+func Test_apiImpl_Execute_cachesTokens(t *testing.T) {
+	badToken := `{"error":{"code":"badtoken","info":"Invalid CSRF token."}}`
+
+	tests := []struct {
+		name         string
+		actions      []Action
+		responses    []string
+		wantErr      error
+		wantRequests []TokenType
+	}{
+		{
+			name:         "Token is reused",
+			actions:      []Action{newWriteAction(), newWriteAction()},
+			responses:    []string{`{"test": 42}`, `{"test": 42}`},
+			wantRequests: []TokenType{TokenTypeCsrf},
+		},
+		{
+			name:         "Tokens are cached by type",
+			actions:      []Action{newWriteAction(), &watchAction{*newWriteAction()}, &watchAction{*newWriteAction()}},
+			responses:    []string{`{"test": 42}`, `{"test": 42}`, `{"test": 42}`},
+			wantRequests: []TokenType{TokenTypeCsrf, TokenTypeWatch},
+		},
+		{
+			name:         "Bad token is requested again",
+			actions:      []Action{newWriteAction(), newWriteAction()},
+			responses:    []string{`{"test": 42}`, badToken, `{"test": 42}`},
+			wantRequests: []TokenType{TokenTypeCsrf, TokenTypeCsrf},
+		},
+		{
+			name:         "Bad token is requested again once",
+			actions:      []Action{newWriteAction()},
+			responses:    []string{badToken, badToken},
+			wantErr:      ErrBadToken,
+			wantRequests: []TokenType{TokenTypeCsrf, TokenTypeCsrf},
+		},
+		{
+			name:      "Read actions are not retried",
+			actions:   []Action{&dummyAction{}},
+			responses: []string{badToken},
+			wantErr:   ErrBadToken,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := &sequenceClient{responses: tt.responses}
+			tokenFn := &mockTokenFn{token: "write-token"}
+			api := NewApi(expectedDestination, client, &mockAuthenticator{token: expectedToken}, tokenFn.tokenFn)
+
+			var err error
+			for _, action := range tt.actions {
+				err = api.Execute(action)
+			}
+
+			if !errors.Is(err, tt.wantErr) || (err == nil) != (tt.wantErr == nil) {
+				t.Errorf("Execute() error = %v, want %v", err, tt.wantErr)
+			}
+
+			if !slices.Equal(tokenFn.requests, tt.wantRequests) {
+				t.Errorf("requested tokens %v, want %v", tokenFn.requests, tt.wantRequests)
+			}
+
+			if client.requests != len(tt.responses) {
+				t.Errorf("sent %d requests, want %d", client.requests, len(tt.responses))
+			}
+		})
+	}
+}
+
+func Test_redactPayload(t *testing.T) {
+	payload := map[string]interface{}{"action": "login", "lgname": "User@Bot", "lgpassword": "secret", "lgtoken": "login-token"}
+
+	got := redactPayload(payload)
+
+	want := map[string]interface{}{"action": "login", "lgname": "User@Bot", "lgpassword": "[redacted]", "lgtoken": "[redacted]"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("redactPayload() = %v, want %v", got, want)
+	}
+
+	if payload["lgpassword"] != "secret" {
+		t.Errorf("redactPayload() must not change the payload")
+	}
+}
+
+func newReadAction() *dummyAction {
+	return &dummyAction{payload: map[string]interface{}{}}
+}
+
+func Test_apiImpl_Execute_maxLag(t *testing.T) {
+	start := time.Date(2022, 4, 20, 12, 13, 14, 0, time.UTC)
+	maxLag := `{"error":{"code":"maxlag","info":"Waiting for 10.64.48.35: 7 seconds lagged.","host":"10.64.48.35","lag":7}}`
+	lagHeaders := http.Header{"Retry-After": {"3"}, "X-Database-Lag": {"7"}}
+
+	tests := []struct {
+		name        string
+		actions     []Action
+		responses   []string
+		headers     []http.Header
+		wantErr     error
+		wantMaxLags []string
+		wantSleeps  []time.Duration
+		wantLag     ReplicationLag
+	}{
+		{
+			name:        "Read is paused as long as the API says",
+			actions:     []Action{newReadAction()},
+			responses:   []string{maxLag, `{"test": 42}`},
+			headers:     []http.Header{lagHeaders},
+			wantMaxLags: []string{"5", "5"},
+			wantSleeps:  []time.Duration{3 * time.Second},
+			wantLag:     ReplicationLag{Lag: 7 * time.Second, ObservedAt: start, PausedUntil: start.Add(3 * time.Second)},
+		},
+		{
+			name:        "Read gives up eventually",
+			actions:     []Action{newReadAction()},
+			responses:   []string{maxLag, maxLag, maxLag, maxLag, maxLag},
+			wantErr:     ErrMaxLag,
+			wantMaxLags: []string{"5", "5", "5", "5", "5"},
+			wantSleeps:  []time.Duration{defaultLagPause, defaultLagPause, defaultLagPause, defaultLagPause},
+			wantLag:     ReplicationLag{Lag: 7 * time.Second, ObservedAt: start.Add(4 * defaultLagPause), PausedUntil: start.Add(5 * defaultLagPause)},
+		},
+		{
+			name:        "Write is not paused nor retried, but pauses the next read",
+			actions:     []Action{newWriteAction(), newWriteAction(), newReadAction()},
+			responses:   []string{maxLag, `{"test": 42}`, `{"test": 42}`},
+			headers:     []http.Header{lagHeaders},
+			wantErr:     ErrMaxLag,
+			wantMaxLags: []string{"10", "10", "5"},
+			wantSleeps:  []time.Duration{3 * time.Second},
+			wantLag:     ReplicationLag{Lag: 7 * time.Second, ObservedAt: start, PausedUntil: start.Add(3 * time.Second)},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := &sequenceClient{responses: tt.responses, headers: tt.headers}
+			api := NewApi(expectedDestination, client, &mockAuthenticator{token: expectedToken}, (&mockTokenFn{}).tokenFn,
+				WithMaxLag(5), WithWriteMaxLag(10)).(*apiImpl)
+
+			now := start
+			var sleeps []time.Duration
+			api.lag.now = func() time.Time { return now }
+			api.lag.sleep = func(d time.Duration) {
+				sleeps = append(sleeps, d)
+				now = now.Add(d)
+			}
+
+			var err error
+			for _, action := range tt.actions {
+				if actionErr := api.Execute(action); err == nil {
+					err = actionErr
+				}
+			}
+
+			if !errors.Is(err, tt.wantErr) || (err == nil) != (tt.wantErr == nil) {
+				t.Errorf("Execute() error = %v, want %v", err, tt.wantErr)
+			}
+
+			var maxLags []string
+			for _, form := range client.forms {
+				maxLags = append(maxLags, form.Get(maxLagKey))
+			}
+			if !slices.Equal(maxLags, tt.wantMaxLags) {
+				t.Errorf("sent maxlag %v, want %v", maxLags, tt.wantMaxLags)
+			}
+
+			if !slices.Equal(sleeps, tt.wantSleeps) {
+				t.Errorf("paused for %v, want %v", sleeps, tt.wantSleeps)
+			}
+
+			if got := api.ReplicationLag(); got != tt.wantLag {
+				t.Errorf("ReplicationLag() = %+v, want %+v", got, tt.wantLag)
+			}
+		})
+	}
+}
+
+func Test_apiImpl_Execute_renewsSessionWhileRequestingToken(t *testing.T) {
+	expired := `{"error":{"code":"assertnameduserfailed","info":"You are no longer logged in as \"User\"."}}`
+
+	client := &sequenceClient{responses: []string{expired, `{"query": {}}`, `{"test": 42}`}}
+	authenticator := &mockRenewableAuthenticator{}
+
+	// Like the application, the token is requested through the API
+	tokenFn := func(api Api, _ TokenType) (Token, error) {
+		return "write-token", api.Execute(newReadAction())
+	}
+
+	api := NewApi(expectedDestination, client, authenticator, tokenFn)
+
+	done := make(chan error)
+	go func() {
+		done <- api.Execute(newWriteAction())
+	}()
+
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Errorf("Execute() error = %v", err)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatalf("Execute() must not block when the session is renewed while requesting a token")
+	}
+
+	if authenticator.renewals != 1 {
+		t.Errorf("Renew() called %d times, want 1", authenticator.renewals)
+	}
+
+	if got := client.forms[2].Get(writeTokenKey); got != "write-token" {
+		t.Errorf("sent token %q, want write-token", got)
+	}
+}
+
+type countingAuthenticator struct {
+	calls int
+}
+
+func (c *countingAuthenticator) Authenticate(req *http.Request, _ url.Values) error {
+	c.calls++
+	req.Header.Set("Authorization", fmt.Sprintf("signature %d", c.calls))
+
+	return nil
+}
+
+type unavailableClient struct {
+	failures       int
+	authorizations []string
+}
+
+func (u *unavailableClient) Do(req *http.Request) (*http.Response, error) {
+	u.authorizations = append(u.authorizations, req.Header.Get("Authorization"))
+
+	if len(u.authorizations) <= u.failures {
+		return &http.Response{StatusCode: http.StatusServiceUnavailable, Body: io.NopCloser(strings.NewReader(""))}, nil
+	}
+
+	return &http.Response{StatusCode: http.StatusOK, Body: io.NopCloser(strings.NewReader(`{"test": 42}`))}, nil
+}
+
+func Test_apiImpl_Execute_authenticatesRetries(t *testing.T) {
+	unavailable := &unavailableClient{failures: 2}
+	client := sentryhttp.NewRetryClient(unavailable, sentryhttp.RetryPolicy{
+		MaxAttempts:       3,
+		RetryableStatuses: []int{http.StatusServiceUnavailable},
+	})
+
+	api := NewApi("https://example.org/w/api.php", client, &countingAuthenticator{}, (&mockTokenFn{}).tokenFn)
+
+	if err := api.Execute(newReadAction()); err != nil {
+		t.Fatalf("Execute() error = %v", err)
+	}
+
+	want := []string{"signature 1", "signature 2", "signature 3"}
+	if !reflect.DeepEqual(unavailable.authorizations, want) {
+		t.Errorf("sent authorizations %v, want every attempt signed anew %v", unavailable.authorizations, want)
+	}
+}
@@ -0,0 +1,21 @@
+package auth
+
+import (
+	gohttp "net/http"
+	"net/url"
+)
+
+// NewBearer authenticates requests with an OAuth 2 access token.
+func NewBearer(accessToken string) *Bearer {
+	return &Bearer{accessToken: accessToken}
+}
+
+type Bearer struct {
+	accessToken string
+}
+
+func (b *Bearer) Authenticate(req *gohttp.Request, _ url.Values) error {
+	req.Header.Set("Authorization", "Bearer "+b.accessToken)
+
+	return nil
+}
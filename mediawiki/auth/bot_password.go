@@ -0,0 +1,106 @@
+package auth
+
+import (
+	"fmt"
+	"freedom-sentry/http"
+	"freedom-sentry/mediawiki"
+	"freedom-sentry/mediawiki/action/login"
+	"freedom-sentry/mediawiki/action/query"
+	"log"
+	gohttp "net/http"
+	"net/url"
+	"strings"
+	"sync"
+)
+
+const assertUserKey = "assertuser"
+
+// NewBotPassword logs in with a bot password created on Special:BotPasswords. The session lives in the cookie
+// jar of the client, which must be the client of the API too.
+func NewBotPassword(endpoint string, client http.Client, name, password string) *BotPassword {
+	b := &BotPassword{
+		name:     name,
+		password: password,
+	}
+
+	b.loginApi = mediawiki.NewApi(endpoint, client, anonymous{}, nil)
+
+	return b
+}
+
+// BotPassword asserts the user in every request, so that an expired session is reported by the API
+// as mediawiki.ErrAssertUserFailed instead of the request being made anonymously. It logs in before the first
+// request and whenever it is renewed.
+type BotPassword struct {
+	loginApi mediawiki.Api
+	name     string
+	password string
+
+	lock       sync.Mutex
+	isLoggedIn bool
+}
+
+func (b *BotPassword) Authenticate(_ *gohttp.Request, form url.Values) error {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	if !b.isLoggedIn {
+		err := b.login()
+		if err != nil {
+			return err
+		}
+	}
+
+	form.Set(assertUserKey, b.userName())
+
+	return nil
+}
+
+func (b *BotPassword) Renew() error {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	b.isLoggedIn = false
+
+	return b.login()
+}
+
+func (b *BotPassword) login() error {
+	tokensQm := &query.TokensMetaQuery{Type: []string{"login"}}
+
+	err := b.loginApi.Execute(query.Query{Meta: []query.Meta{tokensQm}})
+	if err != nil {
+		return fmt.Errorf("failed to retrieve a login token: %w", err)
+	}
+
+	action := &login.Login{
+		Name:     b.name,
+		Password: b.password,
+		Token:    tokensQm.GetTokens().Login,
+	}
+
+	err = b.loginApi.Execute(action)
+	if err != nil {
+		return err
+	}
+
+	log.Println("logged in as", action.GetResult().UserName)
+
+	b.isLoggedIn = true
+
+	return nil
+}
+
+// userName is the account of the bot password, without the name of the bot.
+func (b *BotPassword) userName() string {
+	name, _, _ := strings.Cut(b.name, "@")
+
+	return name
+}
+
+// anonymous leaves requests as they are, for logging in.
+type anonymous struct{}
+
+func (anonymous) Authenticate(*gohttp.Request, url.Values) error {
+	return nil
+}
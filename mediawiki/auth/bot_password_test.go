@@ -0,0 +1,89 @@
+package auth
+
+import (
+	"fmt"
+	"freedom-sentry/mediawiki"
+	"freedom-sentry/mediawiki/action/query"
+	gohttp "net/http"
+	"net/http/cookiejar"
+	"net/http/httptest"
+	"testing"
+)
+
+// fakeWiki issues a session cookie on login and fails assertions of requests without the current session.
+type fakeWiki struct {
+	session int
+	logins  int
+}
+
+func (w *fakeWiki) ServeHTTP(rw gohttp.ResponseWriter, req *gohttp.Request) {
+	_ = req.ParseForm()
+
+	switch {
+	case req.Form.Get("meta") == "tokens":
+		_, _ = fmt.Fprint(rw, `{"query": {"tokens": {"logintoken": "login-token+\\"}}}`)
+	case req.Form.Get("action") == "login":
+		if req.Form.Get("lgtoken") != "login-token+\\" || req.Form.Get("lgname") != "User@Bot" || req.Form.Get("lgpassword") != "secret" {
+			_, _ = fmt.Fprint(rw, `{"login": {"result": "Failed", "reason": "Incorrect username or password entered."}}`)
+			return
+		}
+
+		w.logins++
+		gohttp.SetCookie(rw, &gohttp.Cookie{Name: "session", Value: fmt.Sprint(w.session)})
+		_, _ = fmt.Fprint(rw, `{"login": {"result": "Success", "lguserid": 42, "lgusername": "User"}}`)
+	default:
+		cookie, err := req.Cookie("session")
+		if req.Form.Get("assertuser") != "User" || err != nil || cookie.Value != fmt.Sprint(w.session) {
+			_, _ = fmt.Fprint(rw, `{"error": {"code": "assertnameduserfailed", "info": "You are no longer logged in as \"User\"."}}`)
+			return
+		}
+
+		_, _ = fmt.Fprint(rw, `{"query": {"userinfo": {"id": 42, "name": "User"}}}`)
+	}
+}
+
+func TestBotPassword(t *testing.T) {
+	wiki := &fakeWiki{}
+	server := httptest.NewServer(wiki)
+	defer server.Close()
+
+	jar, _ := cookiejar.New(nil)
+	client := &gohttp.Client{Jar: jar}
+
+	api := mediawiki.NewApi(server.URL, client, NewBotPassword(server.URL, client, "User@Bot", "secret"), nil)
+
+	userinfo := func() (query.Userinfo, error) {
+		userinfoQm := &query.UserinfoMetaQuery{}
+		err := api.Execute(query.Query{Meta: []query.Meta{userinfoQm}})
+
+		return userinfoQm.GetUserinfo(), err
+	}
+
+	if got, err := userinfo(); err != nil || got.Name != "User" {
+		t.Fatalf("Execute() = %v, %v, want to be logged in before the first request", got, err)
+	}
+
+	if _, err := userinfo(); err != nil || wiki.logins != 1 {
+		t.Errorf("Execute() error = %v, logins = %d, want the session to be reused", err, wiki.logins)
+	}
+
+	wiki.session++
+
+	if _, err := userinfo(); err != nil || wiki.logins != 2 {
+		t.Errorf("Execute() error = %v, logins = %d, want to log in again once the session expires", err, wiki.logins)
+	}
+}
+
+func TestBotPassword_wrongPassword(t *testing.T) {
+	server := httptest.NewServer(&fakeWiki{})
+	defer server.Close()
+
+	jar, _ := cookiejar.New(nil)
+	client := &gohttp.Client{Jar: jar}
+
+	api := mediawiki.NewApi(server.URL, client, NewBotPassword(server.URL, client, "User@Bot", "wrong"), nil)
+
+	if err := api.Execute(query.Query{Meta: []query.Meta{&query.UserinfoMetaQuery{}}}); err == nil {
+		t.Errorf("Execute() must fail if the login fails")
+	}
+}
@@ -0,0 +1,148 @@
+package auth
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha1"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"freedom-sentry/util"
+	gohttp "net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const oauth1SignatureMethod = "HMAC-SHA1"
+const oauth1Version = "1.0"
+
+// NewOAuth1 signs requests with the credentials of an owner-only OAuth 1.0a consumer, following RFC 5849.
+func NewOAuth1(consumerKey, consumerSecret, accessToken, accessSecret string, opts ...util.Option[OAuth1]) *OAuth1 {
+	o := &OAuth1{
+		consumerKey:    consumerKey,
+		consumerSecret: consumerSecret,
+		accessToken:    accessToken,
+		accessSecret:   accessSecret,
+		nonceFn:        randomNonce,
+		clock:          time.Now,
+	}
+
+	util.ApplyOptions(o, opts...)
+
+	return o
+}
+
+type OAuth1 struct {
+	consumerKey    string
+	consumerSecret string
+	accessToken    string
+	accessSecret   string
+
+	nonceFn func() string
+	clock   func() time.Time
+}
+
+// Authenticate signs the method, the URL with its query and the form of the request body.
+func (o *OAuth1) Authenticate(req *gohttp.Request, form url.Values) error {
+	nonce := o.nonceFn()
+	if nonce == "" {
+		return fmt.Errorf("failed to generate an OAuth nonce")
+	}
+
+	params := map[string]string{
+		"oauth_consumer_key":     o.consumerKey,
+		"oauth_nonce":            nonce,
+		"oauth_signature_method": oauth1SignatureMethod,
+		"oauth_timestamp":        strconv.FormatInt(o.clock().Unix(), 10),
+		"oauth_token":            o.accessToken,
+		"oauth_version":          oauth1Version,
+	}
+
+	params["oauth_signature"] = o.sign(req.Method, req.URL, form, params)
+
+	keys := make([]string, 0, len(params))
+	for key := range params {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	header := make([]string, len(keys))
+	for i, key := range keys {
+		header[i] = fmt.Sprintf(`%s="%s"`, percentEncode(key), percentEncode(params[key]))
+	}
+
+	req.Header.Set("Authorization", "OAuth "+strings.Join(header, ", "))
+
+	return nil
+}
+
+// sign computes the HMAC-SHA1 signature of the signature base string.
+func (o *OAuth1) sign(method string, u *url.URL, form url.Values, oauthParams map[string]string) string {
+	var pairs [][2]string
+
+	addPairs := func(values url.Values) {
+		for key, vs := range values {
+			for _, v := range vs {
+				pairs = append(pairs, [2]string{percentEncode(key), percentEncode(v)})
+			}
+		}
+	}
+
+	addPairs(u.Query())
+	addPairs(form)
+	for key, value := range oauthParams {
+		pairs = append(pairs, [2]string{percentEncode(key), percentEncode(value)})
+	}
+
+	sort.Slice(pairs, func(i, j int) bool {
+		if pairs[i][0] != pairs[j][0] {
+			return pairs[i][0] < pairs[j][0]
+		}
+
+		return pairs[i][1] < pairs[j][1]
+	})
+
+	normalized := make([]string, len(pairs))
+	for i, pair := range pairs {
+		normalized[i] = pair[0] + "=" + pair[1]
+	}
+
+	baseUrl := url.URL{Scheme: strings.ToLower(u.Scheme), Host: strings.ToLower(u.Host), Path: u.EscapedPath()}
+
+	base := strings.ToUpper(method) + "&" + percentEncode(baseUrl.String()) + "&" + percentEncode(strings.Join(normalized, "&"))
+	key := percentEncode(o.consumerSecret) + "&" + percentEncode(o.accessSecret)
+
+	mac := hmac.New(sha1.New, []byte(key))
+	mac.Write([]byte(base))
+
+	return base64.StdEncoding.EncodeToString(mac.Sum(nil))
+}
+
+// percentEncode escapes everything but the unreserved characters of RFC 3986.
+func percentEncode(s string) string {
+	var builder strings.Builder
+
+	for _, b := range []byte(s) {
+		if 'A' <= b && b <= 'Z' || 'a' <= b && b <= 'z' || '0' <= b && b <= '9' || b == '-' || b == '.' || b == '_' || b == '~' {
+			builder.WriteByte(b)
+		} else {
+			_, _ = fmt.Fprintf(&builder, "%%%02X", b)
+		}
+	}
+
+	return builder.String()
+}
+
+func randomNonce() string {
+	nonce := make([]byte, 16)
+
+	_, err := rand.Read(nonce)
+	if err != nil {
+		return ""
+	}
+
+	return hex.EncodeToString(nonce)
+}
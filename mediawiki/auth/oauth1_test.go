@@ -0,0 +1,115 @@
+package auth
+
+import (
+	gohttp "net/http"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+)
+
+// The example of https://developer.twitter.com/en/docs/authentication/oauth-1-0a/creating-a-signature
+const (
+	twitterConsumerKey    = "xvz1evFS4wEEPTGEFPHBog"
+	twitterConsumerSecret = "kAcSOqF21Fu85e7zjz7ZN2U4ZRhfV3WpwPAoE3Z7kBw"
+	twitterAccessToken    = "370773112-GmHxMAgYyLbNEtIKZeRNFsMKPR9EyMZeS9weJAEb"
+	twitterAccessSecret   = "LswwdoUaIvS8ltyTt5jkRh4J50vUPVVHtR2YPi5kE"
+	twitterNonce          = "kYjzVBB8Y0ZFabxSWbWovY3uYSQ2pTgmZeNu2VS4cg"
+	twitterTimestamp      = 1318622958
+)
+
+func newTwitterOAuth1() *OAuth1 {
+	return NewOAuth1(twitterConsumerKey, twitterConsumerSecret, twitterAccessToken, twitterAccessSecret,
+		WithNonceFn(func() string { return twitterNonce }),
+		WithClock(func() time.Time { return time.Unix(twitterTimestamp, 0) }),
+	)
+}
+
+func TestOAuth1_Authenticate(t *testing.T) {
+	form := url.Values{"status": {"Hello Ladies + Gentlemen, a signed OAuth request!"}}
+
+	req, _ := gohttp.NewRequest(gohttp.MethodPost, "https://api.twitter.com/1.1/statuses/update.json?include_entities=true", strings.NewReader(form.Encode()))
+
+	err := newTwitterOAuth1().Authenticate(req, form)
+	if err != nil {
+		t.Fatalf("Authenticate() error = %v", err)
+	}
+
+	want := `OAuth oauth_consumer_key="xvz1evFS4wEEPTGEFPHBog", ` +
+		`oauth_nonce="kYjzVBB8Y0ZFabxSWbWovY3uYSQ2pTgmZeNu2VS4cg", ` +
+		`oauth_signature="hCtSmYh%2BiHYCEqBWrE7C7hYmtUk%3D", ` +
+		`oauth_signature_method="HMAC-SHA1", ` +
+		`oauth_timestamp="1318622958", ` +
+		`oauth_token="370773112-GmHxMAgYyLbNEtIKZeRNFsMKPR9EyMZeS9weJAEb", ` +
+		`oauth_version="1.0"`
+
+	if got := req.Header.Get("Authorization"); got != want {
+		t.Errorf("Authenticate() header = %v, want %v", got, want)
+	}
+}
+
+func TestOAuth1_sign(t *testing.T) {
+	oauthParams := map[string]string{
+		"oauth_consumer_key":     twitterConsumerKey,
+		"oauth_nonce":            twitterNonce,
+		"oauth_signature_method": oauth1SignatureMethod,
+		"oauth_timestamp":        "1318622958",
+		"oauth_token":            twitterAccessToken,
+		"oauth_version":          oauth1Version,
+	}
+
+	tests := []struct {
+		name   string
+		method string
+		url    string
+		form   url.Values
+		want   string
+	}{
+		{
+			name:   "Query and form",
+			method: gohttp.MethodPost,
+			url:    "https://api.twitter.com/1.1/statuses/update.json?include_entities=true",
+			form:   url.Values{"status": {"Hello Ladies + Gentlemen, a signed OAuth request!"}},
+			want:   "hCtSmYh+iHYCEqBWrE7C7hYmtUk=",
+		},
+		{
+			name:   "Scheme and host are case insensitive",
+			method: "post",
+			url:    "HTTPS://API.Twitter.com/1.1/statuses/update.json?include_entities=true",
+			form:   url.Values{"status": {"Hello Ladies + Gentlemen, a signed OAuth request!"}},
+			want:   "hCtSmYh+iHYCEqBWrE7C7hYmtUk=",
+		},
+		{
+			name:   "Parameters in the query are signed like those in the form",
+			method: gohttp.MethodPost,
+			url:    "https://api.twitter.com/1.1/statuses/update.json?include_entities=true&status=Hello%20Ladies%20%2B%20Gentlemen%2C%20a%20signed%20OAuth%20request%21",
+			want:   "hCtSmYh+iHYCEqBWrE7C7hYmtUk=",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			u, _ := url.Parse(tt.url)
+
+			if got := newTwitterOAuth1().sign(tt.method, u, tt.form, oauthParams); got != tt.want {
+				t.Errorf("sign() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_percentEncode(t *testing.T) {
+	tests := map[string]string{
+		"Ladies + Gentlemen": "Ladies%20%2B%20Gentlemen",
+		"An encoded string!": "An%20encoded%20string%21",
+		"Dogs, Cats & Mice":  "Dogs%2C%20Cats%20%26%20Mice",
+		"☃":                  "%E2%98%83",
+		"-._~":               "-._~",
+	}
+
+	for s, want := range tests {
+		if got := percentEncode(s); got != want {
+			t.Errorf("percentEncode(%q) = %v, want %v", s, got, want)
+		}
+	}
+}
@@ -0,0 +1,20 @@
+package auth
+
+import (
+	"freedom-sentry/util"
+	"time"
+)
+
+// WithNonceFn replaces the random nonce of OAuth 1.0a requests, e.g. with a fixed one in tests.
+func WithNonceFn(nonceFn func() string) util.Option[OAuth1] {
+	return func(o *OAuth1) {
+		o.nonceFn = nonceFn
+	}
+}
+
+// WithClock replaces the source of OAuth 1.0a timestamps.
+func WithClock(clock func() time.Time) util.Option[OAuth1] {
+	return func(o *OAuth1) {
+		o.clock = clock
+	}
+}
@@ -0,0 +1,116 @@
+package mediawiki
+
+import (
+	"errors"
+	"fmt"
+	"golang.org/x/exp/slices"
+	"log"
+)
+
+var (
+	ErrBadToken         = errors.New("badtoken")
+	ErrRateLimited      = errors.New("ratelimited")
+	ErrMaxLag           = errors.New("maxlag")
+	ErrPermissionDenied = errors.New("permissiondenied")
+	ErrReadOnly         = errors.New("readonly")
+	ErrEditConflict     = errors.New("editconflict")
+	// ErrAssertUserFailed means that the request was not made as the asserted user, e.g. the session expired
+	ErrAssertUserFailed = errors.New("assertuserfailed")
+)
+
+var sentinelErrors = map[string]error{
+	"badtoken":         ErrBadToken,
+	"ratelimited":      ErrRateLimited,
+	"maxlag":           ErrMaxLag,
+	"permissiondenied": ErrPermissionDenied,
+	"readonly":         ErrReadOnly,
+	"editconflict":     ErrEditConflict,
+
+	"assertuserfailed":      ErrAssertUserFailed,
+	"assertnameduserfailed": ErrAssertUserFailed,
+}
+
+// ApiError is an error returned by the API in the `error` block of a response.
+// Common error codes can be matched with errors.Is against the sentinel errors of this package.
+type ApiError struct {
+	Code   string
+	Info   string
+	Module string
+	DocRef string
+}
+
+func (e *ApiError) Error() string {
+	if e.Module != "" {
+		return fmt.Sprintf("api error %s in %s: %s", e.Code, e.Module, e.Info)
+	}
+
+	return fmt.Sprintf("api error %s: %s", e.Code, e.Info)
+}
+
+func (e *ApiError) Is(target error) bool {
+	sentinel, ok := sentinelErrors[e.Code]
+
+	return ok && sentinel == target
+}
+
+// Warning is a non-fatal message attached by a module to a response.
+type Warning struct {
+	Module string
+	Text   string
+}
+
+type WarningHandler func(action Action, warnings []Warning)
+
+func parseApiError(payload map[string]interface{}) *ApiError {
+	rawErr, ok := payload["error"].(map[string]interface{})
+	if !ok {
+		return nil
+	}
+
+	apiErr := &ApiError{}
+	apiErr.Code, _ = rawErr["code"].(string)
+	apiErr.Info, _ = rawErr["info"].(string)
+	apiErr.Module, _ = rawErr["module"].(string)
+
+	// The legacy response format puts the reference to the documentation into `*`
+	if apiErr.DocRef, ok = rawErr["docref"].(string); !ok {
+		apiErr.DocRef, _ = rawErr["*"].(string)
+	}
+
+	return apiErr
+}
+
+func parseWarnings(payload map[string]interface{}) []Warning {
+	rawWarnings, ok := payload["warnings"].(map[string]interface{})
+	if !ok {
+		return nil
+	}
+
+	warnings := make([]Warning, 0, len(rawWarnings))
+
+	for module, rawWarning := range rawWarnings {
+		warning, ok := rawWarning.(map[string]interface{})
+		if !ok {
+			continue
+		}
+
+		text, ok := warning["*"].(string)
+		if !ok {
+			text, _ = warning["warnings"].(string)
+		}
+
+		warnings = append(warnings, Warning{Module: module, Text: text})
+	}
+
+	slices.SortFunc(warnings, func(a, b Warning) bool {
+		return a.Module < b.Module
+	})
+
+	return warnings
+}
+
+func logWarnings(action Action, warnings []Warning) {
+	for _, warning := range warnings {
+		log.Printf("api warning from %s module: %s", warning.Module, warning.Text)
+	}
+}
@@ -0,0 +1,41 @@
+package mediawiki
+
+// Log types and actions that bring revisions to a page
+const (
+	LogTypeMove   = "move"
+	LogTypeImport = "import"
+	LogTypeMerge  = "merge"
+	LogTypeDelete = "delete"
+
+	LogActionRestore = "restore"
+)
+
+// LogEvent is a log entry listed in recent changes.
+type LogEvent struct {
+	Type   string
+	Action string
+	// TargetTitle is the title a page was moved to or its history merged into
+	TargetTitle string
+}
+
+// BringsRevisions tells whether the event adds revisions to the history of a page: a move, an import,
+// a history merge or an undeletion.
+func (e LogEvent) BringsRevisions() bool {
+	switch e.Type {
+	case LogTypeMove, LogTypeImport, LogTypeMerge:
+		return true
+	case LogTypeDelete:
+		return e.Action == LogActionRestore
+	default:
+		return false
+	}
+}
+
+// HistoryTitle returns the title of the page which history the event changed, given the title of the log entry.
+func (e LogEvent) HistoryTitle(title string) string {
+	if e.TargetTitle != "" {
+		return e.TargetTitle
+	}
+
+	return title
+}
@@ -0,0 +1,112 @@
+package mediawiki
+
+import (
+	"log"
+	gohttp "net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+const maxLagKey = "maxlag"
+
+// defaultLagPause is how long reads are paused if the API does not say when to retry
+const defaultLagPause = 5 * time.Second
+
+// maxLagReadAttempts limits how many times a read is sent while the replicas are lagging
+const maxLagReadAttempts = 5
+
+// ReplicationLag is the replication lag of the database of the wiki, as last reported by the API.
+type ReplicationLag struct {
+	Lag        time.Duration
+	ObservedAt time.Time
+	// PausedUntil is when reads are sent again, in the past unless the replicas are lagging
+	PausedUntil time.Time
+}
+
+// LagReporter is implemented by APIs exposing the replication lag, e.g. for metrics.
+type LagReporter interface {
+	ReplicationLag() ReplicationLag
+}
+
+// lagMonitor pauses reads once the API rejects a request because of the replication lag. Writes are not
+// paused, they fail with ErrMaxLag instead and are retried by their callers.
+type lagMonitor struct {
+	now   func() time.Time
+	sleep func(time.Duration)
+
+	lock sync.Mutex
+	lag  ReplicationLag
+}
+
+func newLagMonitor() *lagMonitor {
+	return &lagMonitor{
+		now:   time.Now,
+		sleep: time.Sleep,
+	}
+}
+
+func (m *lagMonitor) get() ReplicationLag {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	return m.lag
+}
+
+// observe records the lag of a response, reported in the X-Database-Lag header or in the maxlag error.
+// A maxlag error pauses reads for as long as the Retry-After header says.
+func (m *lagMonitor) observe(header gohttp.Header, payload map[string]interface{}, isMaxLag bool) {
+	lag, isReported := parseSeconds(header.Get("X-Database-Lag"))
+	if !isReported {
+		if rawErr, ok := payload["error"].(map[string]interface{}); ok {
+			var seconds float64
+			seconds, isReported = rawErr["lag"].(float64)
+			lag = time.Duration(seconds * float64(time.Second))
+		}
+	}
+
+	if !isReported && !isMaxLag {
+		return
+	}
+
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	now := m.now()
+
+	if isReported {
+		m.lag.Lag = lag
+		m.lag.ObservedAt = now
+	}
+
+	if !isMaxLag {
+		return
+	}
+
+	pause, ok := parseSeconds(header.Get("Retry-After"))
+	if !ok || pause <= 0 {
+		pause = defaultLagPause
+	}
+
+	if pausedUntil := now.Add(pause); pausedUntil.After(m.lag.PausedUntil) {
+		log.Printf("replicas are lagging %s behind, pausing reads for %s", m.lag.Lag, pause)
+		m.lag.PausedUntil = pausedUntil
+	}
+}
+
+// waitForReads blocks until reads are not paused anymore.
+func (m *lagMonitor) waitForReads() {
+	pause := m.get().PausedUntil.Sub(m.now())
+	if pause > 0 {
+		m.sleep(pause)
+	}
+}
+
+func parseSeconds(value string) (time.Duration, bool) {
+	seconds, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		return 0, false
+	}
+
+	return time.Duration(seconds * float64(time.Second)), true
+}
@@ -0,0 +1,27 @@
+package mediawiki
+
+import "freedom-sentry/util"
+
+// WithWarningHandler replaces the default handler, which logs the warnings returned by the API.
+func WithWarningHandler(fn WarningHandler) util.Option[apiImpl] {
+	return func(api *apiImpl) {
+		api.warningFn = fn
+	}
+}
+
+// WithMaxLag sends the maxlag parameter with every request, so that the API rejects them while the replicas are
+// lagging more seconds behind. Reads are paused then and sent again later.
+func WithMaxLag(seconds int) util.Option[apiImpl] {
+	return func(api *apiImpl) {
+		api.maxLag = seconds
+		api.writeMaxLag = seconds
+	}
+}
+
+// WithWriteMaxLag sends a different maxlag with write actions, which are not paused but fail with ErrMaxLag.
+// Zero leaves maxlag out of writes.
+func WithWriteMaxLag(seconds int) util.Option[apiImpl] {
+	return func(api *apiImpl) {
+		api.writeMaxLag = seconds
+	}
+}
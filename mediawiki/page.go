@@ -0,0 +1,15 @@
+package mediawiki
+
+type PageId uint64
+
+type Page struct {
+	Id        PageId
+	Namespace int
+	// Title is normalized and, if redirects are followed, points to the redirect target
+	Title string
+
+	IsMissing bool
+	IsInvalid bool
+
+	Revisions []Revision
+}
@@ -6,17 +6,39 @@ import (
 )
 
 type RevisionId string
+type RecentChangeId uint64
 
 type Revision struct {
-	Id           RevisionId
-	IsSuppressed bool
+	Id         RevisionId
+	ParentId   RevisionId
+	Visibility Visibility
 
-	Title   string
-	Content string
+	PageId    PageId
+	Namespace int
+	Title     string
+	Content   string
+	// ContentModel is e.g. wikitext or json
+	ContentModel string
+
+	User    string
+	UserId  uint64
+	Comment string
+	Sha1    string
+	Size    int
+	Tags    []string
+
+	// RcId is only set for revisions coming from recent changes
+	RcId RecentChangeId
+	// Log is set instead of Id for log entries coming from recent changes
+	Log *LogEvent
 
 	Timestamp time.Time
 }
 
+func (r Revision) IsSuppressed() bool {
+	return r.Visibility.Has(VisibilitySuppressed)
+}
+
 func RevisionIdFromAny(v interface{}) RevisionId {
 	return RevisionId(fmt.Sprintf("%.f", v))
 }
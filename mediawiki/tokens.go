@@ -0,0 +1,84 @@
+package mediawiki
+
+import "sync"
+
+type Token string
+type TokenType string
+
+const (
+	TokenTypeCsrf   TokenType = "csrf"
+	TokenTypeLogin  TokenType = "login"
+	TokenTypeWatch  TokenType = "watch"
+	TokenTypePatrol TokenType = "patrol"
+)
+
+// TokenRequestFn requests a new token of the type from the API.
+type TokenRequestFn func(api Api, tokenType TokenType) (Token, error)
+
+// TokenTypedAction is implemented by write actions requiring a token other than the CSRF one.
+type TokenTypedAction interface {
+	TokenType() TokenType
+}
+
+func tokenTypeOf(action Action) TokenType {
+	if typed, ok := action.(TokenTypedAction); ok {
+		return typed.TokenType()
+	}
+
+	return TokenTypeCsrf
+}
+
+// tokenCache keeps tokens by type until the API rejects them. Tokens are valid for the whole session, so
+// a single request serves every write action.
+type tokenCache struct {
+	fn TokenRequestFn
+
+	lock   sync.Mutex
+	tokens map[TokenType]Token
+}
+
+func newTokenCache(fn TokenRequestFn) *tokenCache {
+	return &tokenCache{
+		fn:     fn,
+		tokens: make(map[TokenType]Token),
+	}
+}
+
+// get returns the cached token of the type, requesting it if there is none. The token is requested without
+// holding the lock, as the request goes through the API, which invalidates the tokens if the session is renewed.
+func (c *tokenCache) get(api Api, tokenType TokenType) (Token, error) {
+	c.lock.Lock()
+	token, ok := c.tokens[tokenType]
+	c.lock.Unlock()
+
+	if ok {
+		return token, nil
+	}
+
+	token, err := c.fn(api, tokenType)
+	if err != nil {
+		return "", err
+	}
+
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	c.tokens[tokenType] = token
+
+	return token, nil
+}
+
+func (c *tokenCache) invalidate(tokenType TokenType) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	delete(c.tokens, tokenType)
+}
+
+// invalidateAll drops every token, e.g. once the session has changed.
+func (c *tokenCache) invalidateAll() {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	c.tokens = make(map[TokenType]Token)
+}
@@ -0,0 +1,38 @@
+package mediawiki
+
+// Visibility mirrors the rev_deleted bitfield of a revision.
+type Visibility uint8
+
+const (
+	VisibilityTextHidden Visibility = 1 << iota
+	VisibilityCommentHidden
+	VisibilityUserHidden
+	// VisibilitySuppressed hides the other flagged details from administrators as well
+	VisibilitySuppressed
+)
+
+// visibilityKeys are the flags the API sets on revisions with hidden details
+var visibilityKeys = map[string]Visibility{
+	"texthidden":    VisibilityTextHidden,
+	"commenthidden": VisibilityCommentHidden,
+	"userhidden":    VisibilityUserHidden,
+	"suppressed":    VisibilitySuppressed,
+}
+
+// VisibilityFromFlags reads the visibility of a revision from the flags of an API response.
+func VisibilityFromFlags(json map[string]interface{}) Visibility {
+	var v Visibility
+
+	for key, flag := range visibilityKeys {
+		if _, ok := json[key]; ok {
+			v |= flag
+		}
+	}
+
+	return v
+}
+
+// Has reports whether every detail hidden by other is hidden by v as well.
+func (v Visibility) Has(other Visibility) bool {
+	return v&other == other
+}
@@ -1,6 +1,7 @@
 package suppressor
 
 import (
+	"errors"
 	"freedom-sentry/mediawiki"
 	"golang.org/x/exp/slices"
 	"log"
@@ -8,64 +9,206 @@ import (
 	"time"
 )
 
+// batchingSuppressor buffers revisions by page and suppresses them in batches of the same page,
+// as revisiondelete only accepts revisions of its target.
 type batchingSuppressor struct {
-	period     time.Duration
-	size       int
-	suppressor RevisionSuppressor
+	period      time.Duration
+	size        int
+	suppressor  RevisionSuppressor
+	deadLetters DeadLetterSink
 
 	initOnce sync.Once // Constraint to initialize everything below safely
-	buffer   []mediawiki.Revision
+	buffer   map[string][]pendingRevision
+	pages    []string // Titles of the buffer in the order they were first seen
 	lock     sync.Mutex
 
 	drainRequest      chan bool
 	forceDrainRequest chan bool
 }
 
-func (b *batchingSuppressor) SuppressRevisions(revs []mediawiki.Revision) error {
+// pendingRevision is a buffered revision with the handle waiting for its outcome
+type pendingRevision struct {
+	revision mediawiki.Revision
+	handle   *Handle
+}
+
+// resolution is an outcome to deliver to its handle once the buffer is unlocked
+type resolution struct {
+	handle  *Handle
+	outcome Outcome
+}
+
+func (b *batchingSuppressor) SuppressRevisions(revs []mediawiki.Revision) *Handle {
 	b.initOnce.Do(b.init)
 
+	h := newHandle(len(revs))
+
 	if len(revs) == 0 {
-		return nil
+		return h
 	}
 
 	withLock(&b.lock, func() {
-		slices.Grow(b.buffer, len(revs))
 		for _, rev := range revs {
-			b.buffer = append(b.buffer, rev)
+			if _, ok := b.buffer[rev.Title]; !ok {
+				b.pages = append(b.pages, rev.Title)
+			}
+
+			b.buffer[rev.Title] = append(b.buffer[rev.Title], pendingRevision{revision: rev, handle: h})
 		}
 	})
 
-	b.drainRequest <- true
+	b.requestDrain()
+
+	return h
+}
+
+// requestDrain asks for the buffer to be drained without waiting, as callbacks of handles submit revisions from
+// the draining goroutine itself. A request still pending covers the revisions submitted since.
+func (b *batchingSuppressor) requestDrain() {
+	select {
+	case b.drainRequest <- true:
+	default:
+	}
+}
 
-	return nil
+// drainBuffer suppresses full batches only.
+func (b *batchingSuppressor) drainBuffer() []resolution {
+	return b.flush(false)
 }
 
-func (b *batchingSuppressor) drainBuffer() error {
-	var batch []mediawiki.Revision
+// forceDrainBuffer suppresses everything buffered.
+func (b *batchingSuppressor) forceDrainBuffer() []resolution {
+	return b.flush(true)
+}
+
+func (b *batchingSuppressor) flush(isForced bool) []resolution {
+	var resolutions []resolution
+
+	remaining := b.pages[:0]
+
+	for _, title := range b.pages {
+		pending := b.buffer[title]
 
-	if len(b.buffer) >= b.size {
-		batch, b.buffer = b.buffer[:b.size], b.buffer[b.size:]
-		return b.suppressor.SuppressRevisions(batch)
+		for len(pending) >= b.size || (isForced && len(pending) > 0) {
+			delimiter := b.size
+			if len(pending) < delimiter {
+				delimiter = len(pending)
+			}
+
+			var batch []pendingRevision
+			batch, pending = pending[:delimiter], pending[delimiter:]
+
+			resolutions = append(resolutions, b.suppressBatch(batch)...)
+		}
+
+		if len(pending) == 0 {
+			delete(b.buffer, title)
+			continue
+		}
+
+		b.buffer[title] = pending
+		remaining = append(remaining, title)
 	}
 
-	return nil
+	b.pages = remaining
+
+	return resolutions
 }
 
-func (b *batchingSuppressor) forceDrainBuffer() error {
-	var batch []mediawiki.Revision
+func (b *batchingSuppressor) suppressBatch(batch []pendingRevision) []resolution {
+	revs := make([]mediawiki.Revision, len(batch))
+	for i, p := range batch {
+		revs[i] = p.revision
+	}
+
+	outcomes := b.suppressBisecting(revs)
 
-	delimiter := b.size
-	if len(b.buffer) < delimiter {
-		delimiter = len(b.buffer)
+	// The same revision may be pending for more than one submission
+	handles := make(map[mediawiki.RevisionId][]*Handle, len(batch))
+	for _, p := range batch {
+		handles[p.revision.Id] = append(handles[p.revision.Id], p.handle)
 	}
 
-	batch, b.buffer = b.buffer[:delimiter], b.buffer[delimiter:]
+	var failed []Outcome
+	var suppressed []mediawiki.RevisionId
+
+	resolutions := make([]resolution, 0, len(batch))
+	for _, outcome := range outcomes {
+		waiting := handles[outcome.Revision.Id]
+		if len(waiting) == 0 {
+			continue
+		}
 
-	if len(batch) == 0 {
-		return nil
+		if outcome.Err != nil {
+			logSuppressionError(outcome)
+			failed = append(failed, outcome)
+		} else {
+			suppressed = append(suppressed, outcome.Revision.Id)
+		}
+
+		resolutions = append(resolutions, resolution{handle: waiting[0], outcome: outcome})
+		handles[outcome.Revision.Id] = waiting[1:]
 	}
 
-	return b.suppressor.SuppressRevisions(batch)
+	for _, p := range batch {
+		waiting := handles[p.revision.Id]
+		if len(waiting) == 0 {
+			continue
+		}
+
+		outcome := Outcome{Revision: p.revision, Err: errors.New("no outcome returned for the revision")}
+		resolutions = append(resolutions, resolution{handle: waiting[0], outcome: outcome})
+		handles[p.revision.Id] = waiting[1:]
+		failed = append(failed, outcome)
+	}
+
+	if b.deadLetters != nil {
+		if len(failed) > 0 {
+			b.deadLetters.Add(failed)
+		}
+
+		if len(suppressed) > 0 {
+			b.deadLetters.Remove(suppressed)
+		}
+	}
+
+	return resolutions
+}
+
+// suppressBisecting splits batches the API rejected as a whole and retries the halves, until the revisions
+// causing the rejection are isolated and the rest of the batch is suppressed.
+func (b *batchingSuppressor) suppressBisecting(revs []mediawiki.Revision) []Outcome {
+	outcomes := b.suppressor.SuppressRevisions(revs).Wait()
+
+	if len(revs) < 2 || !isBatchRejected(outcomes) {
+		return outcomes
+	}
+
+	log.Printf("batch of %d revisions of [%s] was rejected, retrying in halves", len(revs), revs[0].Title)
+
+	middle := len(revs) / 2
+
+	return append(b.suppressBisecting(revs[:middle]), b.suppressBisecting(revs[middle:])...)
+}
+
+// singleRevisionErrorCodes are the API errors rejecting a whole batch because of one of its revisions. Other
+// errors, e.g. of permissions, the session or the state of the wiki, fail every half alike.
+var singleRevisionErrorCodes = []string{"nosuchrevid", "nosuchlogid", "nosuchrcid"}
+
+// isBatchRejected reports whether every revision failed with an API error that a single bad revision causes.
+func isBatchRejected(outcomes []Outcome) bool {
+	if len(outcomes) == 0 {
+		return false
+	}
+
+	for _, outcome := range outcomes {
+		var apiErr *mediawiki.ApiError
+		if !errors.As(outcome.Err, &apiErr) || !slices.Contains(singleRevisionErrorCodes, apiErr.Code) {
+			return false
+		}
+	}
+
+	return true
 }
 
 func (b *batchingSuppressor) init() {
@@ -73,7 +216,8 @@ func (b *batchingSuppressor) init() {
 		return
 	}
 
-	b.drainRequest = make(chan bool)
+	b.buffer = make(map[string][]pendingRevision)
+	b.drainRequest = make(chan bool, 1)
 	b.forceDrainRequest = make(chan bool)
 
 	if b.period > 0 {
@@ -94,14 +238,22 @@ func (b *batchingSuppressor) init() {
 		for {
 			select {
 			case <-b.forceDrainRequest:
-				withLockErr(&b.lock, b.forceDrainBuffer)
+				resolve(withLockResult(&b.lock, b.forceDrainBuffer))
 			case <-b.drainRequest:
-				withLockErr(&b.lock, b.drainBuffer)
+				resolve(withLockResult(&b.lock, b.drainBuffer))
 			}
 		}
 	}()
 }
 
+// resolve delivers outcomes outside the buffer lock, so callbacks of handles may submit revisions again. Those
+// are suppressed by a later drain.
+func resolve(resolutions []resolution) {
+	for _, r := range resolutions {
+		r.handle.resolve(r.outcome)
+	}
+}
+
 func withLock(lk sync.Locker, fn func()) {
 	lk.Lock()
 	defer lk.Unlock()
@@ -109,12 +261,19 @@ func withLock(lk sync.Locker, fn func()) {
 	fn()
 }
 
-func withLockErr(lk sync.Locker, fn func() error) {
+func withLockResult[T any](lk sync.Locker, fn func() T) T {
 	lk.Lock()
 	defer lk.Unlock()
 
-	err := fn()
-	if err != nil {
-		log.Println(err)
+	return fn()
+}
+
+func logSuppressionError(outcome Outcome) {
+	var revErr *RevisionError
+	if errors.As(outcome.Err, &revErr) {
+		log.Printf("failed to suppress revision %s of [%s], status: %s, errors: %v", revErr.Id, outcome.Revision.Title, revErr.Status, revErr.Errors)
+		return
 	}
+
+	log.Printf("failed to suppress revision %s of [%s]: %v", outcome.Revision.Id, outcome.Revision.Title, outcome.Err)
 }
@@ -2,6 +2,7 @@ package suppressor
 
 import (
 	"freedom-sentry/mediawiki"
+	"golang.org/x/exp/slices"
 	"testing"
 	"time"
 )
@@ -97,6 +98,25 @@ func Test_batchingSuppressor_SuppressRevisions(t *testing.T) {
 			suppressValues: "1,2,3,4,5|6,7,8,9,10|11,12,13,14",
 			forceDrain:     2, // Testing more force drains when the buffer is empty
 		},
+		{
+			name: "Will batch revisions of each page separately",
+			invocations: [][]mediawiki.Revision{
+				{
+					mediawiki.Revision{Id: "1", Title: "A"},
+					mediawiki.Revision{Id: "2", Title: "B"},
+					mediawiki.Revision{Id: "3", Title: "A"},
+				},
+				{
+					mediawiki.Revision{Id: "4", Title: "B"},
+					mediawiki.Revision{Id: "5", Title: "A"},
+					mediawiki.Revision{Id: "6", Title: "A"},
+					mediawiki.Revision{Id: "7", Title: "A"},
+					mediawiki.Revision{Id: "8", Title: "C"},
+				},
+			},
+			suppressValues: "1,3,5,6,7|2,4|8",
+			forceDrain:     1,
+		},
 	}
 
 	for _, tt := range tests {
@@ -107,11 +127,9 @@ func Test_batchingSuppressor_SuppressRevisions(t *testing.T) {
 				suppressor: standard,
 			}
 
+			handles := make([]*Handle, 0, len(tt.invocations))
 			for _, revs := range tt.invocations {
-				if err := batching.SuppressRevisions(revs); err != nil {
-					t.Errorf("SuppressRevisions() must not throw error")
-					return
-				}
+				handles = append(handles, batching.SuppressRevisions(revs))
 			}
 
 			// Wait until force drain executes
@@ -119,8 +137,21 @@ func Test_batchingSuppressor_SuppressRevisions(t *testing.T) {
 				batching.forceDrainRequest <- true
 			}
 
-			// Goroutines might not complete as fast
-			time.Sleep(time.Millisecond)
+			for i, handle := range handles {
+				select {
+				case <-handle.Done():
+				case <-time.After(time.Second):
+					t.Fatalf("SuppressRevisions() handle %d never completed", i)
+				}
+
+				if err := handle.Err(); err != nil {
+					t.Errorf("SuppressRevisions() handle %d error = %v", i, err)
+				}
+
+				if len(handle.Wait()) != len(tt.invocations[i]) {
+					t.Errorf("SuppressRevisions() handle %d has %d outcomes, want %d", i, len(handle.Wait()), len(tt.invocations[i]))
+				}
+			}
 
 			if tt.suppressValues != standard.callHistory {
 				t.Errorf("SuppressRevisions() call pattern [%s], expected [%s]", standard.callHistory, tt.suppressValues)
@@ -128,3 +159,146 @@ func Test_batchingSuppressor_SuppressRevisions(t *testing.T) {
 		})
 	}
 }
+
+// rejectingSuppressor rejects every batch containing one of the bad revisions, like revisiondelete does.
+type rejectingSuppressor struct {
+	mockSuppressor
+	bad map[mediawiki.RevisionId]error
+}
+
+func (m *rejectingSuppressor) SuppressRevisions(revs []mediawiki.Revision) *Handle {
+	m.addHistory(revs)
+
+	for _, rev := range revs {
+		if err, ok := m.bad[rev.Id]; ok {
+			return completedHandle(outcomesWithErr(revs, err))
+		}
+	}
+
+	return completedHandle(outcomesWithErr(revs, nil))
+}
+
+func Test_batchingSuppressor_bisecting(t *testing.T) {
+	badRevision := &mediawiki.ApiError{Code: "nosuchrevid", Info: "There is no revision with ID 3."}
+	rateLimited := &mediawiki.ApiError{Code: "ratelimited", Info: "You've exceeded your rate limit."}
+	permissionDenied := &mediawiki.ApiError{Code: "permissiondenied", Info: "You don't have permission to hide revisions."}
+	sessionExpired := &mediawiki.ApiError{Code: "assertnameduserfailed", Info: "You are no longer logged in as \"User\"."}
+
+	tests := []struct {
+		name            string
+		bad             map[mediawiki.RevisionId]error
+		wantHistory     string
+		wantDeadLetters []mediawiki.RevisionId
+	}{
+		{
+			name:        "No failures",
+			bad:         map[mediawiki.RevisionId]error{},
+			wantHistory: "1,2,3,4,5",
+		},
+		{
+			name:            "One bad revision is isolated",
+			bad:             map[mediawiki.RevisionId]error{"3": badRevision},
+			wantHistory:     "1,2,3,4,5|1,2|3,4,5|3|4,5",
+			wantDeadLetters: []mediawiki.RevisionId{"3"},
+		},
+		{
+			name:            "Two bad revisions are isolated",
+			bad:             map[mediawiki.RevisionId]error{"1": badRevision, "5": badRevision},
+			wantHistory:     "1,2,3,4,5|1,2|1|2|3,4,5|3|4,5|4|5",
+			wantDeadLetters: []mediawiki.RevisionId{"1", "5"},
+		},
+		{
+			name:            "Transient errors are not bisected",
+			bad:             map[mediawiki.RevisionId]error{"3": rateLimited},
+			wantHistory:     "1,2,3,4,5",
+			wantDeadLetters: []mediawiki.RevisionId{"1", "2", "3", "4", "5"},
+		},
+		{
+			name:            "Errors of the account are not bisected",
+			bad:             map[mediawiki.RevisionId]error{"3": permissionDenied},
+			wantHistory:     "1,2,3,4,5",
+			wantDeadLetters: []mediawiki.RevisionId{"1", "2", "3", "4", "5"},
+		},
+		{
+			name:            "Errors of the session are not bisected",
+			bad:             map[mediawiki.RevisionId]error{"3": sessionExpired},
+			wantHistory:     "1,2,3,4,5",
+			wantDeadLetters: []mediawiki.RevisionId{"1", "2", "3", "4", "5"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			standard := &rejectingSuppressor{bad: tt.bad}
+			deadLetters := NewDeadLetterQueue("")
+			batching := &batchingSuppressor{
+				size:        5,
+				suppressor:  standard,
+				deadLetters: deadLetters,
+			}
+
+			revs := []mediawiki.Revision{{Id: "1"}, {Id: "2"}, {Id: "3"}, {Id: "4"}, {Id: "5"}}
+			outcomes := batching.SuppressRevisions(revs).Wait()
+
+			if standard.callHistory != tt.wantHistory {
+				t.Errorf("SuppressRevisions() call pattern [%s], expected [%s]", standard.callHistory, tt.wantHistory)
+			}
+
+			for _, outcome := range outcomes {
+				_, isBad := tt.bad[outcome.Revision.Id]
+				if (outcome.Err != nil) != (isBad || slices.Contains(tt.wantDeadLetters, outcome.Revision.Id)) {
+					t.Errorf("SuppressRevisions() revision %s error = %v", outcome.Revision.Id, outcome.Err)
+				}
+			}
+
+			var got []mediawiki.RevisionId
+			letters, _ := deadLetters.GetAll()
+			for _, letter := range letters {
+				got = append(got, letter.RevisionId)
+
+				if letter.Error == "" || letter.Attempts != 1 {
+					t.Errorf("dead letter %s has error %v after %d attempts", letter.RevisionId, letter.Error, letter.Attempts)
+				}
+			}
+			slices.Sort(got)
+
+			if !slices.Equal(got, tt.wantDeadLetters) {
+				t.Errorf("dead letters = %v, want %v", got, tt.wantDeadLetters)
+			}
+		})
+	}
+}
+
+func Test_batchingSuppressor_resubmitFromCallback(t *testing.T) {
+	standard := &mockSuppressor{}
+	batching := &batchingSuppressor{
+		size:       5,
+		suppressor: standard,
+	}
+
+	resubmitted := make(chan *Handle, 1)
+	batching.SuppressRevisions([]mediawiki.Revision{{Id: "1"}}).OnComplete(func([]Outcome) {
+		resubmitted <- batching.SuppressRevisions([]mediawiki.Revision{{Id: "2"}})
+	})
+
+	batching.forceDrainRequest <- true
+
+	var handle *Handle
+	select {
+	case handle = <-resubmitted:
+	case <-time.After(time.Second):
+		t.Fatalf("SuppressRevisions() from a callback never returned")
+	}
+
+	batching.forceDrainRequest <- true
+
+	select {
+	case <-handle.Done():
+	case <-time.After(time.Second):
+		t.Fatalf("SuppressRevisions() resubmitted handle never completed")
+	}
+
+	if standard.callHistory != "1|2" {
+		t.Errorf("SuppressRevisions() call pattern [%s], expected [1|2]", standard.callHistory)
+	}
+}
@@ -0,0 +1,254 @@
+package suppressor
+
+import (
+	"freedom-sentry/mediawiki"
+	"freedom-sentry/util"
+	"golang.org/x/exp/maps"
+	"golang.org/x/exp/slices"
+	"log"
+	"time"
+)
+
+const (
+	deadLetterMinBackoff = time.Minute
+	deadLetterMaxBackoff = 24 * time.Hour
+)
+
+// DeadLetterStatus tells whether a dead letter is still retried.
+type DeadLetterStatus string
+
+const (
+	// DeadLetterPending revisions are retried once their backoff is over
+	DeadLetterPending DeadLetterStatus = ""
+	// DeadLetterUnlisted revisions are not covered by the suppression list anymore. They are kept for the record
+	// until the operator drops them, and retried if the list covers them again.
+	DeadLetterUnlisted DeadLetterStatus = "unlisted"
+)
+
+// DeadLetter is a revision that could not be suppressed on its own.
+type DeadLetter struct {
+	RevisionId    mediawiki.RevisionId `json:"revid"`
+	Title         string               `json:"title"`
+	User          string               `json:"user,omitempty"`
+	Timestamp     time.Time            `json:"timestamp"`
+	Error         string               `json:"error"`
+	Attempts      int                  `json:"attempts"`
+	FirstSeen     time.Time            `json:"first_seen"`
+	LastSeen      time.Time            `json:"last_seen"`
+	NextAttempt   time.Time            `json:"next_attempt"`
+	Status        DeadLetterStatus     `json:"status,omitempty"`
+	StatusReason  string               `json:"status_reason,omitempty"`
+	StatusChanged time.Time            `json:"status_changed"`
+}
+
+func (l DeadLetter) revision() mediawiki.Revision {
+	return mediawiki.Revision{Id: l.RevisionId, Title: l.Title, User: l.User, Timestamp: l.Timestamp}
+}
+
+// DeadLetterSink receives revisions whose suppression failed even after their batch was split,
+// and hears about revisions suppressed afterwards.
+type DeadLetterSink interface {
+	Add(outcomes []Outcome)
+	Remove(ids []mediawiki.RevisionId)
+}
+
+// DeadLetterQueue keeps failed revisions in a JSON file, so that they survive restarts. The queue is kept
+// in memory only if the path is empty.
+type DeadLetterQueue struct {
+	store *util.JsonFileStore[mediawiki.RevisionId, DeadLetter]
+	now   func() time.Time
+}
+
+func NewDeadLetterQueue(path string) *DeadLetterQueue {
+	return &DeadLetterQueue{
+		store: util.NewJsonFileStore(path, func(letter DeadLetter) mediawiki.RevisionId {
+			return letter.RevisionId
+		}),
+		now: time.Now,
+	}
+}
+
+func (q *DeadLetterQueue) Add(outcomes []Outcome) {
+	err := q.store.Update(func(letters map[mediawiki.RevisionId]DeadLetter) {
+		now := q.now()
+
+		for _, outcome := range outcomes {
+			letter, ok := letters[outcome.Revision.Id]
+			if !ok {
+				letter.FirstSeen = now
+			}
+
+			letter.RevisionId = outcome.Revision.Id
+			letter.Title = outcome.Revision.Title
+			letter.User = outcome.Revision.User
+			letter.Timestamp = outcome.Revision.Timestamp
+			letter.Attempts++
+			letter.LastSeen = now
+			letter.NextAttempt = now.Add(deadLetterBackoff(letter.Attempts))
+
+			if letter.Status != DeadLetterPending {
+				letter.Status = DeadLetterPending
+				letter.StatusReason = ""
+				letter.StatusChanged = now
+			}
+
+			if outcome.Err != nil {
+				letter.Error = outcome.Err.Error()
+			}
+
+			letters[outcome.Revision.Id] = letter
+		}
+	})
+	if err != nil {
+		log.Println("failed to persist dead letters:", err)
+	}
+}
+
+// Remove drops revisions that were suppressed after all.
+func (q *DeadLetterQueue) Remove(ids []mediawiki.RevisionId) {
+	err := q.Drop(ids)
+	if err != nil {
+		log.Println("failed to persist dead letters:", err)
+	}
+}
+
+// Drop removes the revisions from the queue without suppressing them.
+func (q *DeadLetterQueue) Drop(ids []mediawiki.RevisionId) error {
+	return q.store.Update(func(letters map[mediawiki.RevisionId]DeadLetter) {
+		for _, id := range ids {
+			delete(letters, id)
+		}
+	})
+}
+
+// MarkUnlisted keeps the revisions from being retried, as the list does not cover them anymore for the reason given.
+func (q *DeadLetterQueue) MarkUnlisted(ids []mediawiki.RevisionId, reason string) error {
+	return q.store.Update(func(letters map[mediawiki.RevisionId]DeadLetter) {
+		now := q.now()
+
+		for _, id := range ids {
+			letter, ok := letters[id]
+			if !ok || letter.Status == DeadLetterUnlisted {
+				continue
+			}
+
+			letter.Status = DeadLetterUnlisted
+			letter.StatusReason = reason
+			letter.StatusChanged = now
+			letters[id] = letter
+		}
+	})
+}
+
+// ScheduleNow makes the revisions due for the next retry. All of them are scheduled if no ids are given.
+func (q *DeadLetterQueue) ScheduleNow(ids []mediawiki.RevisionId) error {
+	return q.store.Update(func(letters map[mediawiki.RevisionId]DeadLetter) {
+		if len(ids) == 0 {
+			ids = maps.Keys(letters)
+		}
+
+		for _, id := range ids {
+			if letter, ok := letters[id]; ok {
+				letter.NextAttempt = time.Time{}
+				letters[id] = letter
+			}
+		}
+	})
+}
+
+// GetAll returns the queue ordered by the time of the first failure.
+func (q *DeadLetterQueue) GetAll() ([]DeadLetter, error) {
+	var all []DeadLetter
+
+	err := q.store.View(func(letters map[mediawiki.RevisionId]DeadLetter) {
+		all = maps.Values(letters)
+	})
+
+	slices.SortFunc(all, func(a, b DeadLetter) bool {
+		return a.FirstSeen.Before(b.FirstSeen) || (a.FirstSeen.Equal(b.FirstSeen) && a.RevisionId < b.RevisionId)
+	})
+
+	return all, err
+}
+
+// GetDue returns the revisions whose backoff is over.
+func (q *DeadLetterQueue) GetDue() ([]DeadLetter, error) {
+	all, err := q.GetAll()
+	if err != nil {
+		return nil, err
+	}
+
+	now := q.now()
+	due := make([]DeadLetter, 0, len(all))
+
+	for _, letter := range all {
+		if !letter.NextAttempt.After(now) {
+			due = append(due, letter)
+		}
+	}
+
+	return due, nil
+}
+
+// deadLetterBackoff doubles the delay before the next retry with every failed attempt.
+func deadLetterBackoff(attempts int) time.Duration {
+	backoff := deadLetterMinBackoff
+
+	for i := 1; i < attempts && backoff < deadLetterMaxBackoff; i++ {
+		backoff *= 2
+	}
+
+	if backoff > deadLetterMaxBackoff {
+		backoff = deadLetterMaxBackoff
+	}
+
+	return backoff
+}
+
+// ListedFn reports whether an active entry of the suppression list covers the revision.
+type ListedFn func(rev mediawiki.Revision) (bool, error)
+
+// unlistedReason is recorded on dead letters the list does not cover anymore
+const unlistedReason = "no active entry of the suppression list covers the revision, it was removed or has expired"
+
+// RetryDeadLetters submits the due revisions of the queue again and waits for their outcomes. Revisions that
+// fail again are put back to the queue with a longer backoff by the batching suppressor. Revisions the list
+// does not cover anymore are marked as unlisted instead and kept until they are dropped.
+func RetryDeadLetters(queue *DeadLetterQueue, revSuppressor RevisionSuppressor, isListed ListedFn) error {
+	due, err := queue.GetDue()
+	if err != nil || len(due) == 0 {
+		return err
+	}
+
+	revs := make([]mediawiki.Revision, 0, len(due))
+	var unlisted []mediawiki.RevisionId
+
+	for _, letter := range due {
+		rev := letter.revision()
+
+		listed, err := isListed(rev)
+		if err != nil {
+			return err
+		}
+
+		if !listed {
+			if letter.Status != DeadLetterUnlisted {
+				unlisted = append(unlisted, letter.RevisionId)
+			}
+			continue
+		}
+
+		revs = append(revs, rev)
+	}
+
+	if len(unlisted) > 0 {
+		log.Printf("not retrying dead letters the suppression list does not cover anymore: %v", unlisted)
+
+		err = queue.MarkUnlisted(unlisted, unlistedReason)
+		if err != nil {
+			return err
+		}
+	}
+
+	return revSuppressor.SuppressRevisions(revs).Err()
+}
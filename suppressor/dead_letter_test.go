@@ -0,0 +1,151 @@
+package suppressor
+
+import (
+	"errors"
+	"freedom-sentry/mediawiki"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestDeadLetterQueue_persistence(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "dead_letters.json")
+	now := time.Date(2022, 4, 20, 12, 13, 14, 0, time.UTC)
+
+	queue := NewDeadLetterQueue(path)
+	queue.now = func() time.Time { return now }
+
+	queue.Add([]Outcome{
+		{Revision: mediawiki.Revision{Id: "1", Title: "A"}, Err: errors.New("first error")},
+		{Revision: mediawiki.Revision{Id: "2", Title: "B"}, Err: errors.New("second error")},
+	})
+
+	now = now.Add(time.Hour)
+	queue.Add([]Outcome{
+		{Revision: mediawiki.Revision{Id: "1", Title: "A"}, Err: errors.New("third error")},
+	})
+
+	// A new queue reads what the previous instance wrote
+	restarted := NewDeadLetterQueue(path)
+	restarted.now = func() time.Time { return now }
+
+	letters, err := restarted.GetAll()
+	if err != nil {
+		t.Fatalf("GetAll() error = %v", err)
+	}
+
+	if len(letters) != 2 {
+		t.Fatalf("GetAll() = %v, want 2 letters", letters)
+	}
+
+	first := letters[0]
+	if first.RevisionId != "1" || first.Title != "A" || first.Attempts != 2 || first.Error != "third error" {
+		t.Errorf("GetAll() first letter = %+v", first)
+	}
+
+	if !first.FirstSeen.Equal(now.Add(-time.Hour)) || !first.LastSeen.Equal(now) {
+		t.Errorf("GetAll() first letter seen %v - %v", first.FirstSeen, first.LastSeen)
+	}
+
+	if !first.NextAttempt.Equal(now.Add(2 * deadLetterMinBackoff)) {
+		t.Errorf("GetAll() first letter next attempt %v, want backoff doubled", first.NextAttempt)
+	}
+
+	due, _ := restarted.GetDue()
+	if len(due) != 1 || due[0].RevisionId != "2" {
+		t.Errorf("GetDue() = %v, want only revision 2", due)
+	}
+
+	if err := restarted.ScheduleNow([]mediawiki.RevisionId{"1"}); err != nil {
+		t.Fatalf("ScheduleNow() error = %v", err)
+	}
+
+	due, _ = queue.GetDue()
+	if len(due) != 2 {
+		t.Errorf("GetDue() = %v, want both revisions after ScheduleNow()", due)
+	}
+
+	restarted.Remove([]mediawiki.RevisionId{"2"})
+
+	letters, _ = queue.GetAll()
+	if len(letters) != 1 || letters[0].RevisionId != "1" {
+		t.Errorf("GetAll() = %v, want only revision 1 after Remove()", letters)
+	}
+}
+
+func Test_deadLetterBackoff(t *testing.T) {
+	tests := []struct {
+		attempts int
+		want     time.Duration
+	}{
+		{attempts: 1, want: time.Minute},
+		{attempts: 2, want: 2 * time.Minute},
+		{attempts: 5, want: 16 * time.Minute},
+		{attempts: 100, want: deadLetterMaxBackoff},
+	}
+
+	for _, tt := range tests {
+		if got := deadLetterBackoff(tt.attempts); got != tt.want {
+			t.Errorf("deadLetterBackoff(%d) = %v, want %v", tt.attempts, got, tt.want)
+		}
+	}
+}
+
+func TestRetryDeadLetters(t *testing.T) {
+	queue := NewDeadLetterQueue("")
+	queue.Add([]Outcome{
+		{Revision: mediawiki.Revision{Id: "1", Title: "A"}, Err: errors.New("dummy error")},
+		{Revision: mediawiki.Revision{Id: "2", Title: "Unlisted"}, Err: errors.New("dummy error")},
+	})
+	_ = queue.ScheduleNow(nil)
+
+	standard := &mockSuppressor{}
+	batching := &batchingSuppressor{
+		size:        1,
+		suppressor:  standard,
+		deadLetters: queue,
+	}
+
+	isListed := func(rev mediawiki.Revision) (bool, error) {
+		return rev.Title == "A", nil
+	}
+
+	if err := RetryDeadLetters(queue, batching, isListed); err != nil {
+		t.Fatalf("RetryDeadLetters() error = %v", err)
+	}
+
+	if standard.callHistory != "1" {
+		t.Errorf("RetryDeadLetters() call pattern [%s], expected [1]", standard.callHistory)
+	}
+
+	letters, _ := queue.GetAll()
+	if len(letters) != 1 || letters[0].RevisionId != "2" || letters[0].Status != DeadLetterUnlisted || letters[0].StatusReason == "" {
+		t.Fatalf("GetAll() = %+v, want the unlisted revision kept with its status", letters)
+	}
+
+	_ = queue.ScheduleNow(nil)
+
+	if err := RetryDeadLetters(queue, batching, isListed); err != nil {
+		t.Fatalf("RetryDeadLetters() error = %v", err)
+	}
+
+	if standard.callHistory != "1" {
+		t.Errorf("RetryDeadLetters() call pattern [%s], unlisted revisions must not be retried", standard.callHistory)
+	}
+
+	isListed = func(mediawiki.Revision) (bool, error) {
+		return true, nil
+	}
+
+	if err := RetryDeadLetters(queue, batching, isListed); err != nil {
+		t.Fatalf("RetryDeadLetters() error = %v", err)
+	}
+
+	if standard.callHistory != "1|2" {
+		t.Errorf("RetryDeadLetters() call pattern [%s], expected [1|2] once the list covers the revision again", standard.callHistory)
+	}
+
+	if letters, _ := queue.GetAll(); len(letters) != 0 {
+		t.Errorf("GetAll() = %v, suppressed revisions must leave the queue", letters)
+	}
+}
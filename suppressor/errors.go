@@ -0,0 +1,33 @@
+package suppressor
+
+import (
+	"fmt"
+	"freedom-sentry/mediawiki"
+	"strings"
+)
+
+// PartialFailureError lists the revisions of a submission that could not be suppressed.
+type PartialFailureError struct {
+	Failed []Outcome
+}
+
+func (e *PartialFailureError) Error() string {
+	ids := make([]string, len(e.Failed))
+	for i, outcome := range e.Failed {
+		ids[i] = string(outcome.Revision.Id)
+	}
+
+	return fmt.Sprintf("failed to suppress %d revisions: %s", len(e.Failed), strings.Join(ids, ", "))
+}
+
+// RevisionError is the reason the API refused to change a single revision of an accepted batch.
+type RevisionError struct {
+	Id     mediawiki.RevisionId
+	Status string
+	// Errors holds the message keys returned for the revision
+	Errors []string
+}
+
+func (e *RevisionError) Error() string {
+	return fmt.Sprintf("revision %s not changed, status: %s, errors: %v", e.Id, e.Status, e.Errors)
+}
@@ -0,0 +1,139 @@
+package suppressor
+
+import (
+	"freedom-sentry/mediawiki"
+	"sync"
+)
+
+// Outcome is the final result of suppressing a single revision.
+type Outcome struct {
+	Revision mediawiki.Revision
+	// Err is nil when the revision was suppressed or did not need to be
+	Err error
+}
+
+// Handle tracks revisions submitted for suppression until each of them has an outcome.
+type Handle struct {
+	lock      sync.Mutex
+	pending   int
+	outcomes  []Outcome
+	callbacks []func([]Outcome)
+	done      chan struct{}
+}
+
+func newHandle(pending int) *Handle {
+	h := &Handle{
+		pending:  pending,
+		outcomes: make([]Outcome, 0, pending),
+		done:     make(chan struct{}),
+	}
+
+	if pending == 0 {
+		close(h.done)
+	}
+
+	return h
+}
+
+func completedHandle(outcomes []Outcome) *Handle {
+	h := newHandle(len(outcomes))
+	h.resolve(outcomes...)
+
+	return h
+}
+
+// Done is closed once every revision of the handle has an outcome.
+func (h *Handle) Done() <-chan struct{} {
+	return h.done
+}
+
+// Wait blocks until every revision has an outcome.
+func (h *Handle) Wait() []Outcome {
+	<-h.done
+
+	h.lock.Lock()
+	defer h.lock.Unlock()
+
+	return h.outcomes
+}
+
+// Err waits for the outcomes and returns a *PartialFailureError listing the failed revisions, if any.
+func (h *Handle) Err() error {
+	return failureOf(h.Wait())
+}
+
+// OnComplete registers a callback receiving the outcomes once the handle is done. The callback runs
+// immediately if the handle is already done, otherwise in the goroutine that completes the handle.
+func (h *Handle) OnComplete(fn func([]Outcome)) {
+	h.lock.Lock()
+
+	if h.pending > 0 {
+		h.callbacks = append(h.callbacks, fn)
+		h.lock.Unlock()
+		return
+	}
+
+	outcomes := h.outcomes
+	h.lock.Unlock()
+
+	fn(outcomes)
+}
+
+func (h *Handle) resolve(outcomes ...Outcome) {
+	if len(outcomes) == 0 {
+		return
+	}
+
+	h.lock.Lock()
+
+	h.outcomes = append(h.outcomes, outcomes...)
+	h.pending -= len(outcomes)
+
+	if h.pending > 0 {
+		h.lock.Unlock()
+		return
+	}
+
+	callbacks := h.callbacks
+	h.callbacks = nil
+	all := h.outcomes
+	close(h.done)
+
+	h.lock.Unlock()
+
+	for _, fn := range callbacks {
+		fn(all)
+	}
+}
+
+// forward resolves h with the outcomes of other once it completes.
+func (h *Handle) forward(other *Handle) {
+	other.OnComplete(func(outcomes []Outcome) {
+		h.resolve(outcomes...)
+	})
+}
+
+func outcomesWithErr(revs []mediawiki.Revision, err error) []Outcome {
+	outcomes := make([]Outcome, len(revs))
+	for i, rev := range revs {
+		outcomes[i] = Outcome{Revision: rev, Err: err}
+	}
+
+	return outcomes
+}
+
+func failureOf(outcomes []Outcome) error {
+	var failed []Outcome
+
+	for _, outcome := range outcomes {
+		if outcome.Err != nil {
+			failed = append(failed, outcome)
+		}
+	}
+
+	if len(failed) == 0 {
+		return nil
+	}
+
+	return &PartialFailureError{Failed: failed}
+}
@@ -0,0 +1,84 @@
+package suppressor
+
+import (
+	"errors"
+	"freedom-sentry/mediawiki"
+	"testing"
+)
+
+func TestHandle_OnComplete(t *testing.T) {
+	h := newHandle(2)
+
+	var early []Outcome
+	h.OnComplete(func(outcomes []Outcome) {
+		early = outcomes
+	})
+
+	h.resolve(Outcome{Revision: mediawiki.Revision{Id: "1"}})
+
+	if early != nil {
+		t.Fatalf("OnComplete() callback must wait for every outcome")
+	}
+
+	select {
+	case <-h.Done():
+		t.Fatalf("Done() must not be closed while outcomes are pending")
+	default:
+	}
+
+	h.resolve(Outcome{Revision: mediawiki.Revision{Id: "2"}, Err: errors.New("dummy error")})
+
+	if len(early) != 2 {
+		t.Errorf("OnComplete() callback got %d outcomes, want 2", len(early))
+	}
+
+	var late []Outcome
+	h.OnComplete(func(outcomes []Outcome) {
+		late = outcomes
+	})
+
+	if len(late) != 2 {
+		t.Errorf("OnComplete() on a completed handle got %d outcomes, want 2", len(late))
+	}
+
+	var partialErr *PartialFailureError
+	if err := h.Err(); !errors.As(err, &partialErr) || len(partialErr.Failed) != 1 || partialErr.Failed[0].Revision.Id != "2" {
+		t.Errorf("Err() = %v, want a failure of revision 2", err)
+	}
+}
+
+func TestHandle_forward(t *testing.T) {
+	inner := newHandle(1)
+
+	h := newHandle(2)
+	h.resolve(Outcome{Revision: mediawiki.Revision{Id: "1"}})
+	h.forward(inner)
+
+	inner.resolve(Outcome{Revision: mediawiki.Revision{Id: "2"}})
+
+	if got := h.Wait(); len(got) != 2 {
+		t.Errorf("Wait() = %v, want outcomes of both revisions", got)
+	}
+
+	if err := h.Err(); err != nil {
+		t.Errorf("Err() = %v, want nil", err)
+	}
+}
+
+func Test_batchingSuppressor_failedOutcomes(t *testing.T) {
+	batching := &batchingSuppressor{
+		size:       2,
+		suppressor: &mockSuppressor{throwError: true},
+	}
+
+	first := batching.SuppressRevisions([]mediawiki.Revision{{Id: "1"}})
+	second := batching.SuppressRevisions([]mediawiki.Revision{{Id: "1"}, {Id: "2"}})
+	batching.forceDrainRequest <- true
+
+	for _, h := range []*Handle{first, second} {
+		var partialErr *PartialFailureError
+		if err := h.Err(); !errors.As(err, &partialErr) || len(partialErr.Failed) != len(h.Wait()) {
+			t.Errorf("Err() = %v, want every revision of the handle to fail", err)
+		}
+	}
+}
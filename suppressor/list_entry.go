@@ -0,0 +1,358 @@
+package suppressor
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"freedom-sentry/mediawiki"
+	"freedom-sentry/mediawiki/action/revisiondelete"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// The suppression list has one entry per line:
+//
+//	[* ]Title [| option=value]... [# comment]
+//	[* ][[Title]] [| option=value]... [# comment]
+//
+// Blank lines and everything after # are ignored. A title may be written as a wikitext link, optionally
+// with a label ([[Title|label]]), and as a bullet point. Options are separated with |:
+//
+//	hide=content,comment,user  details to hide, user and comment by default
+//	reason=text                why the page is listed
+//	from=2022-04-20            only revisions saved since then are suppressed
+//	until=2022-04-21           only revisions saved before then are suppressed
+//	expires=2023-04-20         the entry is ignored since then
+//
+// A title may be given as a salted hash instead, see TitleIndex.
+//
+// Dates are either YYYY-MM-DD in UTC or RFC 3339 timestamps. Neither # nor | may appear in titles or values,
+// because they are never a part of a valid title.
+//
+// For example:
+//
+//	* [[Main Page]] | hide=content,user | reason=Doxxing # Reported on 2022-04-20
+//	Talk:Main Page | from=2022-04-01 | expires=2023-04-01
+//	sha256:9f86d081884c7d659a2feaa0c55ad015a3bf4f1b2b0b822cd15d6c15b0f00a08 | hide=content
+
+const (
+	// listCommentMarker starts a comment running to the end of the line
+	listCommentMarker   = "#"
+	listBullet          = "*"
+	listOptionSeparator = "|"
+	listDateLayout      = "2006-01-02"
+
+	listOptionHide    = "hide"
+	listOptionReason  = "reason"
+	listOptionFrom    = "from"
+	listOptionUntil   = "until"
+	listOptionExpires = "expires"
+)
+
+// DefaultHideProfile are the details hidden in revisions of entries without the hide option
+var DefaultHideProfile = revisiondelete.VisibilityOf(suppressionHideDetails, mediawiki.TextBoolNo)
+
+// ListEntry is a page of the suppression list. Entries of JSON lists may match pages by a pattern or revisions
+// by their author instead, those apply to recent changes only.
+type ListEntry struct {
+	Title string
+	// Hash is the salted hash of the title in hex, the title is empty unless resolved through a TitleIndex
+	Hash string
+	// Pattern matches titles of pages, if there is no title
+	Pattern *regexp.Regexp
+	// User matches authors of revisions, if there is neither a title nor a pattern
+	User string
+	// Line is the number of the line, starting from 1
+	Line   int
+	Reason string
+	// Hide holds the details to hide in revisions of the page
+	Hide mediawiki.Visibility
+	// From and Until limit the suppression to revisions saved within the period, if set
+	From  time.Time
+	Until time.Time
+	// Expires is the time the entry is ignored from, if set
+	Expires time.Time
+}
+
+// key identifies the page, the pattern or the user of the entry among other entries.
+func (e ListEntry) key() string {
+	switch {
+	case e.Pattern != nil:
+		return "pattern|" + e.Pattern.String()
+	case e.Hash != "":
+		return "hash|" + e.Hash
+	case e.User != "":
+		return "user|" + e.User
+	default:
+		return "title|" + e.Title
+	}
+}
+
+// label names the entry in messages without revealing hashed titles.
+func (e ListEntry) label() string {
+	if e.Hash != "" {
+		return titleHashPrefix + e.Hash
+	}
+
+	return e.Title
+}
+
+// Covers reports whether the revision is to be suppressed according to the entry.
+func (e ListEntry) Covers(rev mediawiki.Revision) bool {
+	if !e.From.IsZero() && rev.Timestamp.Before(e.From) {
+		return false
+	}
+
+	return e.Until.IsZero() || rev.Timestamp.Before(e.Until)
+}
+
+func (e ListEntry) IsExpired(now time.Time) bool {
+	return !e.Expires.IsZero() && !now.Before(e.Expires)
+}
+
+// ListParseError is an invalid line of a text list, or an invalid value of a JSON list.
+type ListParseError struct {
+	Line int
+	// Path points to the invalid value of a JSON list, e.g. $.entries[1].hide
+	Path string
+	Msg  string
+}
+
+func (e *ListParseError) Error() string {
+	if e.Path != "" {
+		return fmt.Sprintf("%s: %s", e.Path, e.Msg)
+	}
+
+	return fmt.Sprintf("line %d: %s", e.Line, e.Msg)
+}
+
+// ListParseErrors are all invalid lines of the suppression list.
+type ListParseErrors []*ListParseError
+
+func (e ListParseErrors) Error() string {
+	msgs := make([]string, len(e))
+	for i, err := range e {
+		msgs[i] = err.Error()
+	}
+
+	return fmt.Sprintf("invalid suppression list: %s", strings.Join(msgs, "; "))
+}
+
+// ParseList reads the entries of the suppression list. Invalid lines are reported in ListParseErrors and
+// skipped, so that the valid entries are returned along with the error.
+func ParseList(content string) ([]ListEntry, error) {
+	lines := strings.Split(content, "\n")
+
+	entries := make([]ListEntry, 0, len(lines))
+	seen := make(map[string]int, len(lines))
+
+	var errs ListParseErrors
+
+	for i, line := range lines {
+		entry, ok, err := parseListLine(line)
+		if err != nil {
+			errs = append(errs, &ListParseError{Line: i + 1, Msg: err.Error()})
+			continue
+		}
+
+		if !ok {
+			continue
+		}
+
+		entry.Line = i + 1
+
+		if firstLine, isDuplicate := seen[entry.key()]; isDuplicate {
+			errs = append(errs, &ListParseError{Line: entry.Line, Msg: fmt.Sprintf("[%s] is listed on line %d already", entry.label(), firstLine)})
+			continue
+		}
+
+		seen[entry.key()] = entry.Line
+		entries = append(entries, entry)
+	}
+
+	if len(errs) > 0 {
+		return entries, errs
+	}
+
+	return entries, nil
+}
+
+// EntryTitles returns the titles of the entries, skipping those without a title.
+func EntryTitles(entries []ListEntry) []string {
+	titles := make([]string, 0, len(entries))
+	for _, entry := range entries {
+		if entry.Title != "" {
+			titles = append(titles, entry.Title)
+		}
+	}
+
+	return titles
+}
+
+// parseListLine reads the entry of a line. It returns false if the line has no entry, e.g. it is blank.
+func parseListLine(line string) (ListEntry, bool, error) {
+	entry := ListEntry{Hide: DefaultHideProfile}
+
+	text := strings.TrimSpace(strings.TrimLeft(listEntryText(line), listBullet+" \t"))
+	if text == "" {
+		return entry, false, nil
+	}
+
+	title, options, err := splitListEntry(text)
+	if err != nil {
+		return entry, false, err
+	}
+
+	if strings.HasPrefix(strings.TrimSpace(title), titleHashPrefix) {
+		entry.Hash, err = parseTitleHash(title)
+	} else {
+		entry.Title, err = normalizeListTitle(title)
+	}
+	if err != nil {
+		return entry, false, err
+	}
+
+	err = entry.applyOptions(options)
+	if err != nil {
+		return entry, false, err
+	}
+
+	return entry, true, nil
+}
+
+// listEntryText returns the line of the list without the comment.
+func listEntryText(line string) string {
+	text, _, _ := strings.Cut(line, listCommentMarker)
+
+	return text
+}
+
+// splitListEntry separates the title from the options, unwrapping a wikitext link.
+func splitListEntry(text string) (string, string, error) {
+	if !strings.HasPrefix(text, "[[") {
+		title, options, _ := strings.Cut(text, listOptionSeparator)
+		return title, options, nil
+	}
+
+	link, rest, ok := strings.Cut(text[2:], "]]")
+	if !ok {
+		return "", "", fmt.Errorf("unclosed link [[%s", link)
+	}
+
+	title, _, _ := strings.Cut(link, listOptionSeparator)
+
+	rest = strings.TrimSpace(rest)
+	if rest == "" {
+		return title, "", nil
+	}
+
+	if !strings.HasPrefix(rest, listOptionSeparator) {
+		return "", "", fmt.Errorf("unexpected %q after the link, options start with %s", rest, listOptionSeparator)
+	}
+
+	return title, rest[len(listOptionSeparator):], nil
+}
+
+func normalizeListTitle(title string) (string, error) {
+	title = strings.TrimSpace(strings.ReplaceAll(title, "_", " "))
+
+	if title == "" {
+		return "", fmt.Errorf("missing title")
+	}
+
+	if strings.ContainsAny(title, "[]{}<>") {
+		return "", fmt.Errorf("invalid title %q", title)
+	}
+
+	return title, nil
+}
+
+// parseTitleHash reads a hashed title, with or without the prefix.
+func parseTitleHash(value string) (string, error) {
+	hash := strings.ToLower(strings.TrimPrefix(strings.TrimSpace(value), titleHashPrefix))
+
+	decoded, err := hex.DecodeString(hash)
+	if err != nil || len(decoded) != sha256.Size {
+		return "", fmt.Errorf("invalid hash %q, expected %s followed by 64 hex digits", value, titleHashPrefix)
+	}
+
+	return hash, nil
+}
+
+func (e *ListEntry) applyOptions(options string) error {
+	if strings.TrimSpace(options) == "" {
+		return nil
+	}
+
+	seen := make(map[string]bool)
+
+	for _, option := range strings.Split(options, listOptionSeparator) {
+		key, value, ok := strings.Cut(option, "=")
+		key, value = strings.TrimSpace(key), strings.TrimSpace(value)
+
+		if !ok || key == "" {
+			return fmt.Errorf("option %q is not key=value", strings.TrimSpace(option))
+		}
+
+		if seen[key] {
+			return fmt.Errorf("option %s is set twice", key)
+		}
+		seen[key] = true
+
+		var err error
+
+		switch key {
+		case listOptionHide:
+			e.Hide, err = parseHideProfile(value)
+		case listOptionReason:
+			e.Reason = value
+		case listOptionFrom:
+			e.From, err = parseListDate(value)
+		case listOptionUntil:
+			e.Until, err = parseListDate(value)
+		case listOptionExpires:
+			e.Expires, err = parseListDate(value)
+		default:
+			err = fmt.Errorf("unknown option %s", key)
+		}
+
+		if err != nil {
+			return err
+		}
+	}
+
+	if !e.From.IsZero() && !e.Until.IsZero() && !e.From.Before(e.Until) {
+		return fmt.Errorf("%s must be before %s", listOptionFrom, listOptionUntil)
+	}
+
+	return nil
+}
+
+func parseHideProfile(value string) (mediawiki.Visibility, error) {
+	details := strings.Split(value, ",")
+
+	for i, detail := range details {
+		details[i] = strings.TrimSpace(detail)
+
+		if !revisiondelete.IsHideDetail(details[i]) {
+			return 0, fmt.Errorf("unknown detail to hide %q, expected %s, %s or %s", details[i],
+				revisiondelete.HideContent, revisiondelete.HideComment, revisiondelete.HideUser)
+		}
+	}
+
+	return revisiondelete.VisibilityOf(details, mediawiki.TextBoolNo), nil
+}
+
+func parseListDate(value string) (time.Time, error) {
+	if date, err := time.Parse(listDateLayout, value); err == nil {
+		return date, nil
+	}
+
+	date, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid date %q, expected YYYY-MM-DD or an RFC 3339 timestamp", value)
+	}
+
+	return date, nil
+}
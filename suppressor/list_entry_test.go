@@ -0,0 +1,135 @@
+package suppressor
+
+import (
+	"errors"
+	"freedom-sentry/mediawiki"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestParseList(t *testing.T) {
+	tests := []struct {
+		name      string
+		content   string
+		want      []ListEntry
+		wantLines []int
+	}{
+		{
+			name:    "Empty",
+			content: "",
+			want:    []ListEntry{},
+		},
+		{
+			name:    "Plain titles, comments and blank lines",
+			content: "# Suppressed pages\nA\n\n  Talk:B_title  # Reported\n",
+			want: []ListEntry{
+				{Title: "A", Line: 2, Hide: DefaultHideProfile},
+				{Title: "Talk:B title", Line: 4, Hide: DefaultHideProfile},
+			},
+		},
+		{
+			name:    "Wikitext links",
+			content: "* [[A]]\n** [[B|Label]]",
+			want: []ListEntry{
+				{Title: "A", Line: 1, Hide: DefaultHideProfile},
+				{Title: "B", Line: 2, Hide: DefaultHideProfile},
+			},
+		},
+		{
+			name:    "Options",
+			content: "* [[A]] | hide=content, user | reason=Doxxing | from=2022-04-20 | until=2022-04-21T12:00:00Z | expires=2023-04-20",
+			want: []ListEntry{
+				{
+					Title:   "A",
+					Line:    1,
+					Reason:  "Doxxing",
+					Hide:    mediawiki.VisibilityTextHidden | mediawiki.VisibilityUserHidden,
+					From:    time.Date(2022, 4, 20, 0, 0, 0, 0, time.UTC),
+					Until:   time.Date(2022, 4, 21, 12, 0, 0, 0, time.UTC),
+					Expires: time.Date(2023, 4, 20, 0, 0, 0, 0, time.UTC),
+				},
+			},
+		},
+		{
+			name:    "Hashed titles",
+			content: "sha256:9F86D081884C7D659A2FEAA0C55AD015A3BF4F1B2B0B822CD15D6C15B0F00A08 | hide=content\nsha256:9f86d0",
+			want: []ListEntry{
+				{Hash: "9f86d081884c7d659a2feaa0c55ad015a3bf4f1b2b0b822cd15d6c15b0f00a08", Line: 1, Hide: mediawiki.VisibilityTextHidden},
+			},
+			wantLines: []int{2},
+		},
+		{
+			name: "Invalid lines are skipped",
+			content: "A\n" +
+				"[[B\n" +
+				"C | hide=everything\n" +
+				"D | colour=red\n" +
+				"E | from=yesterday\n" +
+				"F | reason\n" +
+				"G | from=2022-04-21 | until=2022-04-20\n" +
+				"[[H]] I\n" +
+				"J{{K}}\n" +
+				"A\n" +
+				"L | reason=a | reason=b\n" +
+				"M",
+			want: []ListEntry{
+				{Title: "A", Line: 1, Hide: DefaultHideProfile},
+				{Title: "M", Line: 12, Hide: DefaultHideProfile},
+			},
+			wantLines: []int{2, 3, 4, 5, 6, 7, 8, 9, 10, 11},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseList(tt.content)
+
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ParseList() = %+v, want %+v", got, tt.want)
+			}
+
+			var lines []int
+
+			var parseErrs ListParseErrors
+			if errors.As(err, &parseErrs) {
+				for _, parseErr := range parseErrs {
+					lines = append(lines, parseErr.Line)
+				}
+			} else if err != nil {
+				t.Fatalf("ParseList() error = %v, want ListParseErrors", err)
+			}
+
+			if !reflect.DeepEqual(lines, tt.wantLines) {
+				t.Errorf("ParseList() errors on lines %v, want %v: %v", lines, tt.wantLines, err)
+			}
+		})
+	}
+}
+
+func TestListEntry_Covers(t *testing.T) {
+	entry := ListEntry{
+		From:  time.Date(2022, 4, 20, 0, 0, 0, 0, time.UTC),
+		Until: time.Date(2022, 4, 21, 0, 0, 0, 0, time.UTC),
+	}
+
+	tests := []struct {
+		timestamp time.Time
+		want      bool
+	}{
+		{timestamp: time.Date(2022, 4, 19, 23, 59, 59, 0, time.UTC), want: false},
+		{timestamp: time.Date(2022, 4, 20, 0, 0, 0, 0, time.UTC), want: true},
+		{timestamp: time.Date(2022, 4, 20, 12, 13, 14, 0, time.UTC), want: true},
+		{timestamp: time.Date(2022, 4, 21, 0, 0, 0, 0, time.UTC), want: false},
+	}
+
+	for _, tt := range tests {
+		if got := entry.Covers(mediawiki.Revision{Timestamp: tt.timestamp}); got != tt.want {
+			t.Errorf("Covers(%v) = %v, want %v", tt.timestamp, got, tt.want)
+		}
+	}
+
+	if !(ListEntry{}).Covers(mediawiki.Revision{}) {
+		t.Errorf("Covers() must cover everything without a period")
+	}
+}
@@ -0,0 +1,279 @@
+package suppressor
+
+import (
+	"encoding/json"
+	"fmt"
+	"freedom-sentry/mediawiki"
+	"freedom-sentry/mediawiki/action/revisiondelete"
+	"golang.org/x/exp/maps"
+	"golang.org/x/exp/slices"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// ContentModelJson is the content model of pages holding a JSON document
+const ContentModelJson = "json"
+
+// A suppression list page with the json content model holds a document like:
+//
+//	{
+//		"description": "Optional, ignored",
+//		"entries": [
+//			{"title": "Main Page", "hide": ["content", "user"], "reason": "Doxxing", "expires": "2023-04-20"},
+//			{"pattern": "^User:Bobby Tables/", "from": "2022-04-20", "until": "2022-04-21"},
+//			{"user": "Bobby Tables"},
+//			{"hash": "9f86d081884c7d659a2feaa0c55ad015a3bf4f1b2b0b822cd15d6c15b0f00a08"}
+//		]
+//	}
+//
+// Every entry has exactly one of title, hash (of the title, see TitleIndex), pattern (a regular expression matched
+// against titles) and user (the author of revisions). The other fields mean the same as the options of text lists. User entries cannot
+// set hide, revisions of users are hidden the way the entry of their page says, or by default.
+
+var jsonListKeys = []string{"description", "entries"}
+var jsonEntryKeys = []string{"title", "hash", "pattern", "user", "hide", "reason", "from", "until", "expires"}
+
+// ParseJsonList reads and validates the entries of a JSON suppression list. Every invalid value is reported
+// with its path in ListParseErrors, along with the valid entries.
+func ParseJsonList(content string) ([]ListEntry, error) {
+	var document interface{}
+
+	err := json.Unmarshal([]byte(content), &document)
+	if err != nil {
+		return nil, ListParseErrors{{Path: "$", Msg: err.Error()}}
+	}
+
+	v := &jsonListValidator{}
+	entries := v.list(document)
+
+	if len(v.errs) > 0 {
+		return entries, v.errs
+	}
+
+	return entries, nil
+}
+
+type jsonListValidator struct {
+	errs ListParseErrors
+}
+
+func (v *jsonListValidator) fail(path, format string, args ...interface{}) {
+	v.errs = append(v.errs, &ListParseError{Path: path, Msg: fmt.Sprintf(format, args...)})
+}
+
+func (v *jsonListValidator) object(path string, value interface{}, keys []string) (map[string]interface{}, bool) {
+	object, ok := value.(map[string]interface{})
+	if !ok {
+		v.fail(path, "expected an object")
+		return nil, false
+	}
+
+	isValid := true
+
+	fields := maps.Keys(object)
+	slices.Sort(fields)
+
+	for _, key := range fields {
+		if !slices.Contains(keys, key) {
+			v.fail(path+"."+key, "unknown field, expected one of %s", strings.Join(keys, ", "))
+			isValid = false
+		}
+	}
+
+	return object, isValid
+}
+
+func (v *jsonListValidator) string(path string, value interface{}) (string, bool) {
+	str, ok := value.(string)
+	if !ok {
+		v.fail(path, "expected a string")
+		return "", false
+	}
+
+	str = strings.TrimSpace(str)
+	if str == "" {
+		v.fail(path, "must not be empty")
+		return "", false
+	}
+
+	return str, true
+}
+
+func (v *jsonListValidator) list(document interface{}) []ListEntry {
+	root, _ := v.object("$", document, jsonListKeys)
+	if root == nil {
+		return nil
+	}
+
+	rawEntries, isArray := root["entries"].([]interface{})
+	if !isArray {
+		v.fail("$.entries", "expected an array")
+		return nil
+	}
+
+	entries := make([]ListEntry, 0, len(rawEntries))
+	seen := make(map[string]string, len(rawEntries))
+
+	for i, rawEntry := range rawEntries {
+		path := fmt.Sprintf("$.entries[%d]", i)
+
+		entry, isValid := v.entry(path, rawEntry)
+		if !isValid {
+			continue
+		}
+
+		key := entry.key()
+
+		if firstPath, isDuplicate := seen[key]; isDuplicate {
+			v.fail(path, "listed at %s already", firstPath)
+			continue
+		}
+
+		seen[key] = path
+		entries = append(entries, entry)
+	}
+
+	return entries
+}
+
+func (v *jsonListValidator) entry(path string, value interface{}) (ListEntry, bool) {
+	entry := ListEntry{Hide: DefaultHideProfile}
+
+	object, isValid := v.object(path, value, jsonEntryKeys)
+	if object == nil {
+		return entry, false
+	}
+
+	var matchers []string
+	for _, key := range []string{"title", "hash", "pattern", "user"} {
+		if _, ok := object[key]; ok {
+			matchers = append(matchers, key)
+		}
+	}
+
+	if len(matchers) != 1 {
+		v.fail(path, "expected exactly one of title, hash, pattern and user, got %d", len(matchers))
+		return entry, false
+	}
+
+	matcherPath := path + "." + matchers[0]
+
+	matcher, ok := v.string(matcherPath, object[matchers[0]])
+	if !ok {
+		return entry, false
+	}
+
+	switch matchers[0] {
+	case "title":
+		title, err := normalizeListTitle(matcher)
+		if err != nil {
+			v.fail(matcherPath, "%v", err)
+			isValid = false
+		}
+		entry.Title = title
+	case "hash":
+		hash, err := parseTitleHash(matcher)
+		if err != nil {
+			v.fail(matcherPath, "%v", err)
+			isValid = false
+		}
+		entry.Hash = hash
+	case "pattern":
+		pattern, err := regexp.Compile(matcher)
+		if err != nil {
+			v.fail(matcherPath, "invalid regular expression: %v", err)
+			isValid = false
+		}
+		entry.Pattern = pattern
+	case "user":
+		entry.User = matcher
+
+		if _, ok := object["hide"]; ok {
+			v.fail(path+".hide", "not supported for user entries")
+			isValid = false
+		}
+	}
+
+	if rawHide, ok := object["hide"]; ok && entry.User == "" {
+		entry.Hide, ok = v.hide(path+".hide", rawHide)
+		isValid = isValid && ok
+	}
+
+	if rawReason, ok := object["reason"]; ok {
+		entry.Reason, ok = v.string(path+".reason", rawReason)
+		isValid = isValid && ok
+	}
+
+	dates := []struct {
+		key  string
+		date *time.Time
+	}{
+		{key: "from", date: &entry.From},
+		{key: "until", date: &entry.Until},
+		{key: "expires", date: &entry.Expires},
+	}
+
+	for _, d := range dates {
+		key, date := d.key, d.date
+
+		rawDate, ok := object[key]
+		if !ok {
+			continue
+		}
+
+		value, ok := v.string(path+"."+key, rawDate)
+		if !ok {
+			isValid = false
+			continue
+		}
+
+		parsed, err := parseListDate(value)
+		if err != nil {
+			v.fail(path+"."+key, "%v", err)
+			isValid = false
+			continue
+		}
+
+		*date = parsed
+	}
+
+	if !entry.From.IsZero() && !entry.Until.IsZero() && !entry.From.Before(entry.Until) {
+		v.fail(path+".until", "must be after from")
+		isValid = false
+	}
+
+	return entry, isValid
+}
+
+func (v *jsonListValidator) hide(path string, value interface{}) (mediawiki.Visibility, bool) {
+	rawDetails, ok := value.([]interface{})
+	if !ok || len(rawDetails) == 0 {
+		v.fail(path, "expected a non-empty array")
+		return 0, false
+	}
+
+	details := make([]string, 0, len(rawDetails))
+	isValid := true
+
+	for i, rawDetail := range rawDetails {
+		detailPath := fmt.Sprintf("%s[%d]", path, i)
+
+		detail, ok := v.string(detailPath, rawDetail)
+		if !ok {
+			isValid = false
+			continue
+		}
+
+		if !revisiondelete.IsHideDetail(detail) {
+			v.fail(detailPath, "unknown detail %q, expected %s, %s or %s", detail,
+				revisiondelete.HideContent, revisiondelete.HideComment, revisiondelete.HideUser)
+			isValid = false
+			continue
+		}
+
+		details = append(details, detail)
+	}
+
+	return revisiondelete.VisibilityOf(details, mediawiki.TextBoolNo), isValid
+}
@@ -0,0 +1,145 @@
+package suppressor
+
+import (
+	"errors"
+	"freedom-sentry/mediawiki"
+	"reflect"
+	"regexp"
+	"testing"
+	"time"
+)
+
+func TestParseJsonList(t *testing.T) {
+	tests := []struct {
+		name      string
+		content   string
+		want      []ListEntry
+		wantPaths []string
+	}{
+		{
+			name:      "Not JSON",
+			content:   "A\nB",
+			wantPaths: []string{"$"},
+		},
+		{
+			name:      "Not an object",
+			content:   `["A"]`,
+			wantPaths: []string{"$"},
+		},
+		{
+			name:      "No entries",
+			content:   `{"description": "Empty"}`,
+			wantPaths: []string{"$.entries"},
+		},
+		{
+			name: "Valid entries",
+			content: `{"description": "Suppressed pages", "entries": [
+				{"title": "Main_Page", "hide": ["content", "user"], "reason": "Doxxing", "expires": "2023-04-20"},
+				{"pattern": "^User:Bobby Tables/", "from": "2022-04-20", "until": "2022-04-21T12:00:00Z"},
+				{"user": "Bobby Tables"},
+				{"hash": "9f86d081884c7d659a2feaa0c55ad015a3bf4f1b2b0b822cd15d6c15b0f00a08"}
+			]}`,
+			want: []ListEntry{
+				{
+					Title:   "Main Page",
+					Reason:  "Doxxing",
+					Hide:    mediawiki.VisibilityTextHidden | mediawiki.VisibilityUserHidden,
+					Expires: time.Date(2023, 4, 20, 0, 0, 0, 0, time.UTC),
+				},
+				{
+					Pattern: regexp.MustCompile(`^User:Bobby Tables/`),
+					Hide:    DefaultHideProfile,
+					From:    time.Date(2022, 4, 20, 0, 0, 0, 0, time.UTC),
+					Until:   time.Date(2022, 4, 21, 12, 0, 0, 0, time.UTC),
+				},
+				{
+					User: "Bobby Tables",
+					Hide: DefaultHideProfile,
+				},
+				{
+					Hash: "9f86d081884c7d659a2feaa0c55ad015a3bf4f1b2b0b822cd15d6c15b0f00a08",
+					Hide: DefaultHideProfile,
+				},
+			},
+		},
+		{
+			name: "Invalid values are reported with paths",
+			content: `{"entries": [
+				{"title": "A"},
+				{"title": "B", "pattern": "C"},
+				{"title": "D", "hide": ["content", "everything"]},
+				{"pattern": "(E"},
+				{"user": "F", "hide": ["user"]},
+				{"title": "G", "from": "yesterday", "colour": "red"},
+				{"title": "H", "from": "2022-04-21", "until": "2022-04-20"},
+				{"title": "A"},
+				"I",
+				{"title": 42},
+				{"hash": "abc"}
+			], "version": 2}`,
+			want: []ListEntry{
+				{Title: "A", Hide: DefaultHideProfile},
+			},
+			wantPaths: []string{
+				"$.version",
+				"$.entries[1]",
+				"$.entries[2].hide[1]",
+				"$.entries[3].pattern",
+				"$.entries[4].hide",
+				"$.entries[5].colour",
+				"$.entries[5].from",
+				"$.entries[6].until",
+				"$.entries[7]",
+				"$.entries[8]",
+				"$.entries[9].title",
+				"$.entries[10].hash",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseJsonList(tt.content)
+
+			if len(got) != len(tt.want) {
+				t.Fatalf("ParseJsonList() = %+v, want %+v", got, tt.want)
+			}
+
+			for i := range got {
+				gotEntry, gotPattern := comparableEntry(got[i])
+				wantEntry, wantPattern := comparableEntry(tt.want[i])
+
+				if !reflect.DeepEqual(gotEntry, wantEntry) || gotPattern != wantPattern {
+					t.Errorf("ParseJsonList() entry %d = %+v, want %+v", i, got[i], tt.want[i])
+				}
+			}
+
+			var paths []string
+
+			var parseErrs ListParseErrors
+			if errors.As(err, &parseErrs) {
+				for _, parseErr := range parseErrs {
+					paths = append(paths, parseErr.Path)
+				}
+			} else if err != nil {
+				t.Fatalf("ParseJsonList() error = %v, want ListParseErrors", err)
+			}
+
+			if !reflect.DeepEqual(paths, tt.wantPaths) {
+				t.Errorf("ParseJsonList() errors at %v, want %v: %v", paths, tt.wantPaths, err)
+			}
+		})
+	}
+}
+
+// comparableEntry replaces the pattern of the entry with its source, so that entries can be compared
+func comparableEntry(entry ListEntry) (ListEntry, string) {
+	if entry.Pattern == nil {
+		return entry, ""
+	}
+
+	pattern := entry.Pattern.String()
+	entry.Pattern = nil
+
+	return entry, pattern
+}
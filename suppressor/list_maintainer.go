@@ -0,0 +1,288 @@
+package suppressor
+
+import (
+	"errors"
+	"fmt"
+	"freedom-sentry/mediawiki"
+	"freedom-sentry/mediawiki/action/edit"
+	"freedom-sentry/util"
+	"golang.org/x/exp/maps"
+	"log"
+	"strings"
+	"time"
+)
+
+const (
+	maintenanceSectionHeader = "# == Maintenance =="
+	maintenanceSummary       = "Updating moved and missing pages of the suppression list"
+	missingNote              = "missing"
+)
+
+// ListMaintainer corrects the suppression list page: moved titles are replaced by their targets and missing
+// pages are marked.
+type ListMaintainer interface {
+	MaintainList() error
+}
+
+func NewListMaintainer(api mediawiki.Api, revRepo RevisionRepository, moves *MoveTracker, listName string, opts ...util.Option[listMaintainerImpl]) ListMaintainer {
+	lm := &listMaintainerImpl{
+		api:      api,
+		revRepo:  revRepo,
+		moves:    moves,
+		listName: listName,
+	}
+
+	util.ApplyOptions(lm, opts...)
+
+	return lm
+}
+
+type listMaintainerImpl struct {
+	api      mediawiki.Api
+	revRepo  RevisionRepository
+	moves    *MoveTracker
+	listName string
+
+	isDryRun bool
+}
+
+// MaintainList saves the corrected list based on its latest revision. An edit conflict is returned as
+// mediawiki.ErrEditConflict, the next run starts over from the newer revision.
+func (lm listMaintainerImpl) MaintainList() error {
+	startedAt := time.Now()
+
+	rev, err := lm.revRepo.GetLatestRevision(lm.listName)
+	if err != nil {
+		return err
+	}
+
+	if rev.Id == "" {
+		return fmt.Errorf("suppression list [%s] does not exist", lm.listName)
+	}
+
+	if rev.ContentModel == ContentModelJson {
+		log.Printf("suppression list [%s] is a JSON document, only text lists are corrected", lm.listName)
+		return nil
+	}
+
+	entries, _ := ParseList(rev.Content)
+	list := EntryTitles(entries)
+
+	moved := make(map[string]string)
+	current := make([]string, 0, len(list))
+
+	for _, title := range list {
+		to, err := lm.moves.Resolve(title)
+		if err != nil {
+			return err
+		}
+
+		if to != title {
+			moved[title] = to
+		}
+
+		current = append(current, to)
+	}
+
+	pages, err := lm.revRepo.GetPagesByNames(current)
+	if err != nil {
+		return err
+	}
+
+	missing := make(map[string]bool)
+	for _, title := range current {
+		if page, ok := pages[title]; !ok || page.IsMissing || page.IsInvalid {
+			missing[title] = true
+		}
+	}
+
+	corrected := correctList(rev.Content, moved, missing)
+	if strings.TrimSpace(corrected) == strings.TrimSpace(rev.Content) {
+		return nil
+	}
+
+	if lm.isDryRun {
+		log.Printf("dry run, not saving corrections of [%s]:\n%s", lm.listName, lineDiff(rev.Content, corrected))
+		return nil
+	}
+
+	action := edit.Edit{
+		Title:          lm.listName,
+		Text:           corrected,
+		Summary:        maintenanceSummary,
+		BaseRevisionId: rev.Id,
+		StartTimestamp: startedAt,
+		NoCreate:       true,
+	}
+
+	err = lm.api.Execute(&action)
+	if errors.Is(err, mediawiki.ErrEditConflict) {
+		log.Printf("suppression list [%s] changed since revision %s, postponing corrections", lm.listName, rev.Id)
+		return err
+	}
+	if err != nil {
+		return err
+	}
+
+	log.Printf("saved corrections of [%s] as revision %s", lm.listName, action.GetResult().NewRevisionId)
+
+	// The list has the new titles now
+	return lm.moves.Forget(maps.Keys(moved))
+}
+
+// correctList replaces titles of moved entries with their targets, marks missing entries and rewrites
+// the maintenance section at the end of the list. Options and other lines are kept as they are.
+func correctList(content string, moved map[string]string, missing map[string]bool) string {
+	lines := removeMaintenanceSection(strings.Split(content, "\n"))
+
+	for len(lines) > 0 && strings.TrimSpace(lines[len(lines)-1]) == "" {
+		lines = lines[:len(lines)-1]
+	}
+
+	var missingEntries []string
+
+	for i, line := range lines {
+		parsed, ok, err := parseListLine(line)
+		if !ok || err != nil {
+			continue
+		}
+
+		entry := parsed.Title
+
+		if to, ok := moved[entry]; ok {
+			lines[i] = appendListNote(replaceListTitle(line, entry, to), "moved from "+entry)
+
+			entry = to
+		}
+
+		if !missing[entry] {
+			// The page has been created since
+			lines[i] = removeListNote(lines[i], missingNote)
+			continue
+		}
+
+		missingEntries = append(missingEntries, entry)
+		lines[i] = appendListNote(lines[i], missingNote)
+	}
+
+	if len(missingEntries) > 0 {
+		lines = append(lines, "", maintenanceSectionHeader)
+		lines = append(lines, fmt.Sprintf("%s %d listed pages do not exist:", listCommentMarker, len(missingEntries)))
+
+		for _, entry := range missingEntries {
+			lines = append(lines, fmt.Sprintf("%s * %s", listCommentMarker, entry))
+		}
+	}
+
+	return strings.Join(lines, "\n")
+}
+
+// appendListNote adds the note to the comment of the line, starting one if there is none. Notes are separated
+// by semicolons, a note already at the end is not added again.
+func appendListNote(line, note string) string {
+	if !strings.Contains(line, listCommentMarker) {
+		return fmt.Sprintf("%s %s %s", line, listCommentMarker, note)
+	}
+
+	if removeListNote(line, note) != line {
+		return line
+	}
+
+	return fmt.Sprintf("%s; %s", line, note)
+}
+
+// removeListNote removes the note from the end of the comment of the line, along with the comment if nothing
+// else is left.
+func removeListNote(line, note string) string {
+	if trimmed := strings.TrimSuffix(line, " "+listCommentMarker+" "+note); trimmed != line {
+		return trimmed
+	}
+
+	if strings.Contains(line, listCommentMarker) {
+		return strings.TrimSuffix(line, "; "+note)
+	}
+
+	return line
+}
+
+// removeMaintenanceSection removes the section generated by the maintainer, the header and the comments below
+// it. Lines added after the section by editors are kept.
+func removeMaintenanceSection(lines []string) []string {
+	for i, line := range lines {
+		if line != maintenanceSectionHeader {
+			continue
+		}
+
+		end := i + 1
+		for end < len(lines) && strings.HasPrefix(strings.TrimSpace(lines[end]), listCommentMarker) {
+			end++
+		}
+
+		start := i
+		if start > 0 && strings.TrimSpace(lines[start-1]) == "" {
+			start--
+		}
+
+		return append(lines[:start:start], lines[end:]...)
+	}
+
+	return lines
+}
+
+// replaceListTitle replaces the title on the line, written either with spaces or with underscores.
+func replaceListTitle(line, title, to string) string {
+	text := listEntryText(line)
+
+	for _, written := range []string{title, strings.ReplaceAll(title, " ", "_")} {
+		if i := strings.Index(text, written); i >= 0 {
+			return line[:i] + to + line[i+len(written):]
+		}
+	}
+
+	return line
+}
+
+// lineDiff returns the lines of both texts, those removed from the old text prefixed with - and those added
+// to the new one with +.
+func lineDiff(oldText, newText string) string {
+	a := strings.Split(oldText, "\n")
+	b := strings.Split(newText, "\n")
+
+	// lcs[i][j] is the length of the longest common subsequence of a[i:] and b[j:]
+	lcs := make([][]int, len(a)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(b)+1)
+	}
+
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			if a[i] == b[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else if lcs[i+1][j] >= lcs[i][j+1] {
+				lcs[i][j] = lcs[i+1][j]
+			} else {
+				lcs[i][j] = lcs[i][j+1]
+			}
+		}
+	}
+
+	var diff strings.Builder
+	i, j := 0, 0
+
+	for i < len(a) || j < len(b) {
+		switch {
+		case i < len(a) && j < len(b) && a[i] == b[j]:
+			diff.WriteString("  " + a[i] + "\n")
+			i++
+			j++
+		case i < len(a) && (j == len(b) || lcs[i+1][j] >= lcs[i][j+1]):
+			diff.WriteString("- " + a[i] + "\n")
+			i++
+		default:
+			diff.WriteString("+ " + b[j] + "\n")
+			j++
+		}
+	}
+
+	return diff.String()
+}
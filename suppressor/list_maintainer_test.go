@@ -0,0 +1,108 @@
+package suppressor
+
+import (
+	"reflect"
+	"testing"
+)
+
+func Test_correctList(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		moved   map[string]string
+		missing map[string]bool
+		want    string
+	}{
+		{
+			name:    "Nothing to correct",
+			content: "A\nB # Comment",
+			want:    "A\nB # Comment",
+		},
+		{
+			name:    "Moved entry is replaced",
+			content: "A\n  B\nC",
+			moved:   map[string]string{"B": "D"},
+			want:    "A\n  D # moved from B\nC",
+		},
+		{
+			name:    "Options of a moved entry are kept",
+			content: "* [[B_title]] | hide=content\n* [[C]] # Comment",
+			moved:   map[string]string{"B title": "D", "C": "E"},
+			want:    "* [[D]] | hide=content # moved from B title\n* [[E]] # Comment; moved from C",
+		},
+		{
+			name:    "Missing entries are marked and summarized",
+			content: "A\nB # Kept comment\nC",
+			missing: map[string]bool{"A": true, "B": true},
+			want: "A # missing\nB # Kept comment; missing\nC\n\n" +
+				"# == Maintenance ==\n# 2 listed pages do not exist:\n# * A\n# * B",
+		},
+		{
+			name: "Maintenance section is rewritten",
+			content: "A # missing\nB\n\n" +
+				"# == Maintenance ==\n# 1 listed pages do not exist:\n# * A\n",
+			missing: map[string]bool{"B": true},
+			want: "A\nB # missing\n\n" +
+				"# == Maintenance ==\n# 1 listed pages do not exist:\n# * B",
+		},
+		{
+			name: "Entries added below the maintenance section are kept",
+			content: "Gone\n\n" +
+				"# == Maintenance ==\n# 1 listed pages do not exist:\n# * Gone\nNew Page | hide=content\n",
+			missing: map[string]bool{"Gone": true},
+			want: "Gone # missing\nNew Page | hide=content\n\n" +
+				"# == Maintenance ==\n# 1 listed pages do not exist:\n# * Gone",
+		},
+		{
+			name: "Entries added below the maintenance section are kept once the pages exist",
+			content: "Gone # missing\n\n" +
+				"# == Maintenance ==\n# 1 listed pages do not exist:\n# * Gone\n\nNew Page\n",
+			want: "Gone\n\nNew Page",
+		},
+		{
+			name:    "Moved to a missing page",
+			content: "A",
+			moved:   map[string]string{"A": "B"},
+			missing: map[string]bool{"B": true},
+			want: "B # moved from A; missing\n\n" +
+				"# == Maintenance ==\n# 1 listed pages do not exist:\n# * B",
+		},
+		{
+			name: "Commented entry stays marked once",
+			content: "A # Kept comment; missing\n\n" +
+				"# == Maintenance ==\n# 1 listed pages do not exist:\n# * A",
+			missing: map[string]bool{"A": true},
+			want: "A # Kept comment; missing\n\n" +
+				"# == Maintenance ==\n# 1 listed pages do not exist:\n# * A",
+		},
+		{
+			name:    "Mark of a commented entry is removed once the page exists",
+			content: "A # moved from B; missing",
+			want:    "A # moved from B",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := correctList(tt.content, tt.moved, tt.missing)
+			if got != tt.want {
+				t.Errorf("correctList() = %q, want %q", got, tt.want)
+			}
+
+			got2, _ := ParseList(got)
+			want2, _ := ParseList(tt.content)
+			if entries := got2; len(entries) != len(want2) {
+				t.Errorf("correctList() changed the number of entries to %v", entries)
+			}
+		})
+	}
+}
+
+func Test_lineDiff(t *testing.T) {
+	got := lineDiff("A\nB\nC", "A\nD # moved from B\nC\nE")
+	want := "  A\n- B\n+ D # moved from B\n  C\n+ E\n"
+
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("lineDiff() = %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,133 @@
+package suppressor
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"log"
+	"strings"
+)
+
+// ListVersion identifies the content of a list source, e.g. a revision of a wiki page or a hash of a file.
+// Versions of a source are only compared for equality.
+type ListVersion string
+
+// ListSource provides the entries of a suppression list.
+type ListSource interface {
+	// Name describes the source in logs.
+	Name() string
+	// Fetch returns the valid entries of the list and the version they were read from, invalid ones are logged.
+	Fetch() ([]ListEntry, ListVersion, error)
+	// Version returns the current version of the list, which differs from the fetched one once the list changes.
+	Version() (ListVersion, error)
+}
+
+// contentVersion is the version of a source without revisions of its own.
+func contentVersion(content []byte) ListVersion {
+	hash := sha256.Sum256(content)
+
+	return ListVersion(hex.EncodeToString(hash[:]))
+}
+
+// listParser reads the content of a source either as a text list or as a JSON document. A JSON version with any
+// invalid value is ignored as a whole, as long as there has been a valid one before.
+type listParser struct {
+	name string
+
+	// lastValid are the entries of the latest valid version of a JSON list
+	lastValid []ListEntry
+}
+
+func (p *listParser) parse(content string, version ListVersion, isJson bool) []ListEntry {
+	if !isJson {
+		entries, err := ParseList(content)
+		if err != nil {
+			log.Printf("skipping invalid entries of %s: %v", p.name, err)
+		}
+
+		return entries
+	}
+
+	entries, err := ParseJsonList(content)
+	if err == nil {
+		p.lastValid = entries
+		return entries
+	}
+
+	if p.lastValid != nil {
+		log.Printf("version %s of %s is invalid, keeping the previous list: %v", version, p.name, err)
+		return p.lastValid
+	}
+
+	log.Printf("skipping invalid entries of %s: %v", p.name, err)
+
+	return entries
+}
+
+// NewCompositeListSource unions the entries of the sources. An entry listed by several sources is taken from
+// the first one.
+func NewCompositeListSource(sources ...ListSource) ListSource {
+	if len(sources) == 1 {
+		return sources[0]
+	}
+
+	return &compositeListSource{sources: sources}
+}
+
+type compositeListSource struct {
+	sources []ListSource
+}
+
+func (c *compositeListSource) Name() string {
+	names := make([]string, len(c.sources))
+	for i, source := range c.sources {
+		names[i] = source.Name()
+	}
+
+	return strings.Join(names, ", ")
+}
+
+// Fetch fails if any of the sources fails, so that a partial list is never taken for the whole one.
+func (c *compositeListSource) Fetch() ([]ListEntry, ListVersion, error) {
+	var entries []ListEntry
+	versions := make([]string, len(c.sources))
+	seen := make(map[string]string)
+
+	for i, source := range c.sources {
+		sourceEntries, version, err := source.Fetch()
+		if err != nil {
+			return nil, "", fmt.Errorf("failed to read %s: %w", source.Name(), err)
+		}
+
+		versions[i] = string(version)
+
+		for _, entry := range sourceEntries {
+			key := entry.key()
+
+			if firstSource, isDuplicate := seen[key]; isDuplicate {
+				log.Printf("skipping an entry of %s listed by %s already", source.Name(), firstSource)
+				continue
+			}
+
+			seen[key] = source.Name()
+			entries = append(entries, entry)
+		}
+	}
+
+	return entries, ListVersion(strings.Join(versions, "|")), nil
+}
+
+func (c *compositeListSource) Version() (ListVersion, error) {
+	versions := make([]string, len(c.sources))
+
+	for i, source := range c.sources {
+		version, err := source.Version()
+		if err != nil {
+			return "", fmt.Errorf("failed to check %s: %w", source.Name(), err)
+		}
+
+		versions[i] = string(version)
+	}
+
+	return ListVersion(strings.Join(versions, "|")), nil
+}
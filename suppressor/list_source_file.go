@@ -0,0 +1,47 @@
+package suppressor
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// NewFileListSource reads the list from a local file, which is never published on the wiki. Files with
+// the .json extension hold a JSON document.
+func NewFileListSource(path string) ListSource {
+	return &fileListSource{
+		path:   path,
+		parser: listParser{name: "file " + path},
+	}
+}
+
+type fileListSource struct {
+	path   string
+	parser listParser
+}
+
+func (f *fileListSource) Name() string {
+	return f.parser.name
+}
+
+func (f *fileListSource) Fetch() ([]ListEntry, ListVersion, error) {
+	content, err := os.ReadFile(f.path)
+	if err != nil {
+		return nil, "", err
+	}
+
+	version := contentVersion(content)
+	isJson := strings.EqualFold(filepath.Ext(f.path), ".json")
+
+	return f.parser.parse(string(content), version, isJson), version, nil
+}
+
+// Version hashes the content of the file, so that it changes with every edit regardless of timestamps.
+func (f *fileListSource) Version() (ListVersion, error) {
+	content, err := os.ReadFile(f.path)
+	if err != nil {
+		return "", err
+	}
+
+	return contentVersion(content), nil
+}
@@ -0,0 +1,213 @@
+package suppressor
+
+import (
+	"errors"
+	"freedom-sentry/mediawiki"
+	"io"
+	gohttp "net/http"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+type mockListSource struct {
+	name    string
+	entries []ListEntry
+	version ListVersion
+	err     error
+}
+
+func (m *mockListSource) Name() string {
+	return m.name
+}
+
+func (m *mockListSource) Fetch() ([]ListEntry, ListVersion, error) {
+	return m.entries, m.version, m.err
+}
+
+func (m *mockListSource) Version() (ListVersion, error) {
+	return m.version, m.err
+}
+
+type mockListRevisionRepository struct {
+	RevisionRepository
+	rev mediawiki.Revision
+}
+
+func (m *mockListRevisionRepository) GetLatestRevision(string) (mediawiki.Revision, error) {
+	return m.rev, nil
+}
+
+func (m *mockListRevisionRepository) GetPagesByNames(names []string) (map[string]mediawiki.Page, error) {
+	pages := make(map[string]mediawiki.Page)
+	if m.rev.Id != "" {
+		pages[names[0]] = mediawiki.Page{Title: names[0], Revisions: []mediawiki.Revision{m.rev}}
+	}
+
+	return pages, nil
+}
+
+type mockHttpClient struct {
+	contentType string
+	body        string
+	status      int
+}
+
+func (m *mockHttpClient) Do(*gohttp.Request) (*gohttp.Response, error) {
+	res := &gohttp.Response{
+		StatusCode: m.status,
+		Status:     gohttp.StatusText(m.status),
+		Header:     gohttp.Header{},
+		Body:       io.NopCloser(strings.NewReader(m.body)),
+	}
+	res.Header.Set("Content-Type", m.contentType)
+
+	return res, nil
+}
+
+func entryTitlesOf(t *testing.T, source ListSource) ([]string, ListVersion) {
+	t.Helper()
+
+	entries, version, err := source.Fetch()
+	if err != nil {
+		t.Fatalf("Fetch() error = %v", err)
+	}
+
+	return EntryTitles(entries), version
+}
+
+func Test_wikiPageListSource(t *testing.T) {
+	revRepo := &mockListRevisionRepository{}
+	source := NewWikiPageListSource(revRepo, "List")
+
+	if _, _, err := source.Fetch(); err == nil {
+		t.Errorf("Fetch() of a missing page must fail")
+	}
+
+	revRepo.rev = mediawiki.Revision{Id: "1", Content: "* [[A]]"}
+
+	if titles, version := entryTitlesOf(t, source); !reflect.DeepEqual(titles, []string{"A"}) || version != "1" {
+		t.Errorf("Fetch() = %v, %v, want [A], 1", titles, version)
+	}
+
+	revRepo.rev = mediawiki.Revision{Id: "2", ContentModel: ContentModelJson, Content: `{"entries": [{"title": "B"}]}`}
+
+	if version, _ := source.Version(); version != "2" {
+		t.Errorf("Version() = %v, want 2", version)
+	}
+
+	if titles, _ := entryTitlesOf(t, source); !reflect.DeepEqual(titles, []string{"B"}) {
+		t.Errorf("Fetch() = %v, want [B]", titles)
+	}
+
+	revRepo.rev = mediawiki.Revision{Id: "3", ContentModel: ContentModelJson, Content: `{"entries": [{"title": "C"}, {"title": ""}]}`}
+
+	if titles, version := entryTitlesOf(t, source); !reflect.DeepEqual(titles, []string{"B"}) || version != "3" {
+		t.Errorf("Fetch() = %v, %v, an invalid revision must keep [B]", titles, version)
+	}
+}
+
+func Test_fileListSource(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "list.json")
+	source := NewFileListSource(path)
+
+	if _, err := source.Version(); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("Version() error = %v, want os.ErrNotExist", err)
+	}
+
+	if err := os.WriteFile(path, []byte(`{"entries": [{"title": "A"}]}`), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	titles, fetched := entryTitlesOf(t, source)
+	if !reflect.DeepEqual(titles, []string{"A"}) {
+		t.Errorf("Fetch() = %v, want [A]", titles)
+	}
+
+	if version, _ := source.Version(); version != fetched {
+		t.Errorf("Version() = %v, want %v of the unchanged file", version, fetched)
+	}
+
+	if err := os.WriteFile(path, []byte(`{"entries": [{"title": "B"}]}`), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	if version, _ := source.Version(); version == fetched {
+		t.Errorf("Version() must change with the content")
+	}
+}
+
+func Test_urlListSource(t *testing.T) {
+	client := &mockHttpClient{status: gohttp.StatusOK, contentType: "application/json", body: `{"entries": [{"title": "A"}]}`}
+	source := NewUrlListSource(client, "https://www.example.org/list")
+
+	if titles, _ := entryTitlesOf(t, source); !reflect.DeepEqual(titles, []string{"A"}) {
+		t.Errorf("Fetch() = %v, want [A]", titles)
+	}
+
+	client.contentType = "text/plain"
+	client.body = "* [[B]]\nC"
+
+	if titles, _ := entryTitlesOf(t, source); !reflect.DeepEqual(titles, []string{"B", "C"}) {
+		t.Errorf("Fetch() = %v, want [B C]", titles)
+	}
+
+	client.status = gohttp.StatusNotFound
+
+	if _, _, err := source.Fetch(); err == nil {
+		t.Errorf("Fetch() must fail on an unexpected status")
+	}
+}
+
+func Test_compositeListSource(t *testing.T) {
+	first := &mockListSource{name: "first", version: "1", entries: []ListEntry{{Title: "A"}, {Title: "B"}}}
+	second := &mockListSource{name: "second", version: "a", entries: []ListEntry{{Title: "B", Reason: "Again"}, {User: "C"}}}
+	source := NewCompositeListSource(first, second)
+
+	entries, version, err := source.Fetch()
+	if err != nil {
+		t.Fatalf("Fetch() error = %v", err)
+	}
+
+	want := []ListEntry{{Title: "A"}, {Title: "B"}, {User: "C"}}
+	if !reflect.DeepEqual(entries, want) || version != "1|a" {
+		t.Errorf("Fetch() = %+v, %v, want %+v, 1|a", entries, version, want)
+	}
+
+	second.version = "b"
+
+	if version, _ := source.Version(); version != "1|b" {
+		t.Errorf("Version() = %v, want 1|b", version)
+	}
+
+	second.err = errors.New("unavailable")
+
+	if _, _, err := source.Fetch(); err == nil {
+		t.Errorf("Fetch() must fail if any of the sources fails")
+	}
+}
+
+func Test_cachingSuppressedPageRepoImpl_HasChanged(t *testing.T) {
+	source := &mockListSource{name: "source", version: "1", entries: []ListEntry{{Title: "A"}}}
+	repo, _ := NewPageRepository(source)
+
+	if hasChanged, _ := repo.HasChanged(); hasChanged {
+		t.Errorf("HasChanged() must be false before the list is read")
+	}
+
+	if _, version, err := repo.GetAll(); err != nil || version != "1" {
+		t.Fatalf("GetAll() = %v, %v, want version 1", version, err)
+	}
+
+	if hasChanged, _ := repo.HasChanged(); hasChanged {
+		t.Errorf("HasChanged() must be false for the same version")
+	}
+
+	source.version = "2"
+
+	if hasChanged, _ := repo.HasChanged(); !hasChanged {
+		t.Errorf("HasChanged() must be true for a new version")
+	}
+}
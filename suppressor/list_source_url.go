@@ -0,0 +1,81 @@
+package suppressor
+
+import (
+	"fmt"
+	"freedom-sentry/http"
+	"freedom-sentry/util"
+	"io"
+	gohttp "net/http"
+	"net/url"
+	"strings"
+)
+
+// NewUrlListSource downloads the list over HTTP. Responses with a JSON content type, or URLs ending
+// with .json, hold a JSON document.
+func NewUrlListSource(client http.Client, listUrl string) ListSource {
+	return &urlListSource{
+		client: client,
+		url:    listUrl,
+		parser: listParser{name: listUrl},
+	}
+}
+
+type urlListSource struct {
+	client http.Client
+	url    string
+	parser listParser
+}
+
+func (u *urlListSource) Name() string {
+	return u.parser.name
+}
+
+func (u *urlListSource) Fetch() ([]ListEntry, ListVersion, error) {
+	content, isJson, err := u.download()
+	if err != nil {
+		return nil, "", err
+	}
+
+	version := contentVersion(content)
+
+	return u.parser.parse(string(content), version, isJson), version, nil
+}
+
+// Version downloads the list again, lists are small and servers do not always tell whether they changed.
+func (u *urlListSource) Version() (ListVersion, error) {
+	content, _, err := u.download()
+	if err != nil {
+		return "", err
+	}
+
+	return contentVersion(content), nil
+}
+
+func (u *urlListSource) download() ([]byte, bool, error) {
+	req, err := gohttp.NewRequest(gohttp.MethodGet, u.url, nil)
+	if err != nil {
+		return nil, false, err
+	}
+
+	res, err := u.client.Do(req)
+	if err != nil {
+		return nil, false, err
+	}
+	defer util.Close(res.Body)
+
+	if res.StatusCode != gohttp.StatusOK {
+		return nil, false, fmt.Errorf("unexpected status of %s: %s", u.url, res.Status)
+	}
+
+	content, err := io.ReadAll(res.Body)
+	if err != nil {
+		return nil, false, err
+	}
+
+	isJson := strings.Contains(res.Header.Get("Content-Type"), "json")
+	if parsed, err := url.Parse(u.url); err == nil && strings.HasSuffix(strings.ToLower(parsed.Path), ".json") {
+		isJson = true
+	}
+
+	return content, isJson, nil
+}
@@ -0,0 +1,53 @@
+package suppressor
+
+import "fmt"
+
+// NewWikiPageListSource reads the list from the latest revision of a wiki page. Pages with the json content
+// model hold a JSON document. The version is the revision id.
+func NewWikiPageListSource(revRepo RevisionRepository, title string) ListSource {
+	return &wikiPageListSource{
+		revRepo: revRepo,
+		title:   title,
+		parser:  listParser{name: "[" + title + "]"},
+	}
+}
+
+type wikiPageListSource struct {
+	revRepo RevisionRepository
+	title   string
+	parser  listParser
+}
+
+func (w *wikiPageListSource) Name() string {
+	return w.parser.name
+}
+
+func (w *wikiPageListSource) Fetch() ([]ListEntry, ListVersion, error) {
+	rev, err := w.revRepo.GetLatestRevision(w.title)
+	if err != nil {
+		return nil, "", err
+	}
+
+	if rev.Id == "" {
+		return nil, "", fmt.Errorf("suppression list [%s] does not exist", w.title)
+	}
+
+	version := ListVersion(rev.Id)
+
+	return w.parser.parse(rev.Content, version, rev.ContentModel == ContentModelJson), version, nil
+}
+
+// Version looks up the latest revision id without the content.
+func (w *wikiPageListSource) Version() (ListVersion, error) {
+	pages, err := w.revRepo.GetPagesByNames([]string{w.title})
+	if err != nil {
+		return "", err
+	}
+
+	page, ok := pages[w.title]
+	if !ok || page.IsMissing || page.IsInvalid || len(page.Revisions) == 0 {
+		return "", fmt.Errorf("suppression list [%s] does not exist", w.title)
+	}
+
+	return ListVersion(page.Revisions[0].Id), nil
+}
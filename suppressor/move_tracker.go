@@ -0,0 +1,88 @@
+package suppressor
+
+import (
+	"freedom-sentry/util"
+	"golang.org/x/exp/maps"
+	"golang.org/x/exp/slices"
+	"time"
+)
+
+// Move maps a title of the suppression list to the title the page has been moved to since.
+type Move struct {
+	From    string    `json:"from"`
+	To      string    `json:"to"`
+	MovedAt time.Time `json:"moved_at"`
+}
+
+// MoveTracker keeps moves of listed pages in a JSON file, so that they are followed after restarts. The moves
+// are kept in memory only if the path is empty.
+type MoveTracker struct {
+	store *util.JsonFileStore[string, Move]
+}
+
+func NewMoveTracker(path string) *MoveTracker {
+	return &MoveTracker{
+		store: util.NewJsonFileStore(path, func(move Move) string {
+			return move.From
+		}),
+	}
+}
+
+// Record follows a move of the page. Earlier moves ending at the old title are carried on to the new one,
+// and a page moved back to its original title is not tracked anymore.
+func (t *MoveTracker) Record(from, to string, movedAt time.Time) error {
+	return t.store.Update(func(moves map[string]Move) {
+		isChained := false
+
+		for original, move := range moves {
+			if move.To != from {
+				continue
+			}
+
+			isChained = true
+			move.To = to
+			move.MovedAt = movedAt
+			moves[original] = move
+		}
+
+		if !isChained {
+			moves[from] = Move{From: from, To: to, MovedAt: movedAt}
+		}
+
+		delete(moves, to)
+	})
+}
+
+// Resolve returns the current title of the page listed under the given one.
+func (t *MoveTracker) Resolve(title string) (string, error) {
+	move, ok, err := t.store.Get(title)
+	if !ok {
+		return title, err
+	}
+
+	return move.To, err
+}
+
+// Forget stops tracking moves of the given titles, e.g. once the list has been corrected.
+func (t *MoveTracker) Forget(titles []string) error {
+	return t.store.Update(func(moves map[string]Move) {
+		for _, title := range titles {
+			delete(moves, title)
+		}
+	})
+}
+
+// GetAll returns the tracked moves ordered by the original title.
+func (t *MoveTracker) GetAll() ([]Move, error) {
+	var all []Move
+
+	err := t.store.View(func(moves map[string]Move) {
+		all = maps.Values(moves)
+	})
+
+	slices.SortFunc(all, func(a, b Move) bool {
+		return a.From < b.From
+	})
+
+	return all, err
+}
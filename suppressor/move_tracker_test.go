@@ -0,0 +1,84 @@
+package suppressor
+
+import (
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestMoveTracker_Record(t *testing.T) {
+	movedAt := time.Date(2022, 4, 20, 12, 13, 14, 0, time.UTC)
+
+	tests := []struct {
+		name  string
+		moves [][2]string
+		want  map[string]string
+	}{
+		{
+			name:  "Single move",
+			moves: [][2]string{{"A", "B"}},
+			want:  map[string]string{"A": "B", "B": "B", "C": "C"},
+		},
+		{
+			name:  "Chained moves are collapsed",
+			moves: [][2]string{{"A", "B"}, {"B", "C"}},
+			want:  map[string]string{"A": "C", "B": "B", "C": "C"},
+		},
+		{
+			name:  "Move back to the original title",
+			moves: [][2]string{{"A", "B"}, {"B", "A"}},
+			want:  map[string]string{"A": "A", "B": "B"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tracker := NewMoveTracker("")
+
+			for _, move := range tt.moves {
+				if err := tracker.Record(move[0], move[1], movedAt); err != nil {
+					t.Fatalf("Record() error = %v", err)
+				}
+			}
+
+			got := make(map[string]string, len(tt.want))
+			for title := range tt.want {
+				got[title], _ = tracker.Resolve(title)
+			}
+
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Resolve() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMoveTracker_persistence(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "moves.json")
+	movedAt := time.Date(2022, 4, 20, 12, 13, 14, 0, time.UTC)
+
+	tracker := NewMoveTracker(path)
+	_ = tracker.Record("A", "B", movedAt)
+	_ = tracker.Record("C", "D", movedAt)
+
+	restarted := NewMoveTracker(path)
+
+	moves, err := restarted.GetAll()
+	if err != nil {
+		t.Fatalf("GetAll() error = %v", err)
+	}
+
+	want := []Move{{From: "A", To: "B", MovedAt: movedAt}, {From: "C", To: "D", MovedAt: movedAt}}
+	if !reflect.DeepEqual(moves, want) {
+		t.Errorf("GetAll() = %v, want %v", moves, want)
+	}
+
+	if err := restarted.Forget([]string{"A"}); err != nil {
+		t.Fatalf("Forget() error = %v", err)
+	}
+
+	if title, _ := NewMoveTracker(path).Resolve("A"); title != "A" {
+		t.Errorf("Resolve() = %s, a forgotten move must not be followed", title)
+	}
+}
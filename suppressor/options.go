@@ -0,0 +1,17 @@
+package suppressor
+
+import "freedom-sentry/util"
+
+// WithBotChanges makes the repository list changes flagged as bot edits among recent changes.
+func WithBotChanges(includesBotChanges bool) util.Option[revRepoImpl] {
+	return func(rr *revRepoImpl) {
+		rr.includesBotChanges = includesBotChanges
+	}
+}
+
+// WithListDryRun makes the list maintainer log the corrections as a diff instead of saving them.
+func WithListDryRun(isDryRun bool) util.Option[listMaintainerImpl] {
+	return func(lm *listMaintainerImpl) {
+		lm.isDryRun = isDryRun
+	}
+}
@@ -1,21 +1,23 @@
 package suppressor
 
 import (
-	"log"
-	"strings"
+	"sync"
 	"time"
 )
 
 type SuppressedPageRepository interface {
-	GetAll() ([]string, error)
+	// GetAll returns the valid entries of the list with its version, invalid lines are logged and skipped.
+	GetAll() ([]ListEntry, ListVersion, error)
+	// HasChanged reports whether the version of the source differs from the one of the cached list. Nothing has
+	// changed until the list has been read.
+	HasChanged() (bool, error)
 }
 
-func NewPageRepository(revRepo RevisionRepository, listName string) (SuppressedPageRepository, chan bool) {
+// NewPageRepository caches the entries of the source for a day. Sending to the returned channel makes the next
+// call read the source again.
+func NewPageRepository(source ListSource) (SuppressedPageRepository, chan bool) {
 	repo := &cachingSuppressedPageRepoImpl{
-		repo: &suppressedPageRepoImpl{
-			revRepo:  revRepo,
-			listName: listName,
-		},
+		source: source,
 	}
 
 	repo.purgeChan = make(chan bool)
@@ -24,7 +26,9 @@ func NewPageRepository(revRepo RevisionRepository, listName string) (SuppressedP
 		for {
 			select {
 			case <-repo.purgeChan:
+				repo.lock.Lock()
 				repo.timestamp = time.Time{}
+				repo.lock.Unlock()
 			}
 		}
 	}()
@@ -32,60 +36,46 @@ func NewPageRepository(revRepo RevisionRepository, listName string) (SuppressedP
 	return repo, repo.purgeChan
 }
 
-type suppressedPageRepoImpl struct {
-	revRepo  RevisionRepository
-	listName string
-}
-
-func (p suppressedPageRepoImpl) GetAll() ([]string, error) {
-	suppressedPagesStr, err := p.revRepo.GetLatestPageContent(p.listName)
-	if err != nil {
-		log.Println("failed to retrieve the list of suppressed pages")
-		return nil, err
-	}
-
-	suppressedPages := rawPageListToSlice(suppressedPagesStr)
-	return suppressedPages, nil
-}
-
-func rawPageListToSlice(suppressedPagesStr string) []string {
-	lines := strings.Split(suppressedPagesStr, "\n")
-
-	list := make([]string, 0, len(lines))
-	for _, line := range lines {
-		trimmed := strings.TrimSpace(line)
-
-		if trimmed == "" {
-			continue
-		}
-
-		list = append(list, trimmed)
-	}
-
-	return list
-}
-
 type cachingSuppressedPageRepoImpl struct {
-	list      []string
+	source ListSource
+
+	lock      sync.Mutex
+	list      []ListEntry
+	version   ListVersion
 	timestamp time.Time
-	repo      SuppressedPageRepository
 
 	purgeChan chan bool
 }
 
-func (c *cachingSuppressedPageRepoImpl) GetAll() ([]string, error) {
-	if !c.timestamp.IsZero() || time.Now().Sub(c.timestamp) < 24*time.Hour {
-		return c.list, nil
+func (c *cachingSuppressedPageRepoImpl) GetAll() ([]ListEntry, ListVersion, error) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	if !c.timestamp.IsZero() && time.Now().Sub(c.timestamp) < 24*time.Hour {
+		return c.list, c.version, nil
 	}
 
-	list, err := c.repo.GetAll()
+	list, version, err := c.source.Fetch()
 	if err != nil {
 		c.timestamp = time.Time{}
-		return nil, err
+		return nil, "", err
 	}
 
 	c.list = list
+	c.version = version
 	c.timestamp = time.Now()
 
-	return list, nil
+	return list, version, nil
+}
+
+func (c *cachingSuppressedPageRepoImpl) HasChanged() (bool, error) {
+	version, err := c.source.Version()
+	if err != nil {
+		return false, err
+	}
+
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	return c.version != "" && version != c.version, nil
 }
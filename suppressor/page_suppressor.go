@@ -1,11 +1,18 @@
 package suppressor
 
 import (
+	"fmt"
+	"freedom-sentry/mediawiki"
 	"log"
 )
 
 type PageSuppressor interface {
-	SuppressPageByName(name string) error
+	// SuppressEntry submits the revisions of the page covered by the entry, the handle completes once all of them
+	// have an outcome.
+	SuppressEntry(entry ListEntry) (*Handle, error)
+	// SuppressEntries resolves the titles in batches and suppresses the pages of the entries, skipping missing
+	// and invalid titles. It returns once every revision has an outcome.
+	SuppressEntries(entries []ListEntry) error
 }
 
 func NewPageSuppressor(revRepo RevisionRepository, revSuppressor RevisionSuppressor) PageSuppressor {
@@ -20,15 +27,73 @@ type pageSuppressorImpl struct {
 	revSuppressor RevisionSuppressor
 }
 
-func (ps pageSuppressorImpl) SuppressPageByName(name string) error {
-	log.Println("retrieving revisions for page:", name)
-	revs, err := ps.revRepo.GetAllByPageName(name)
+func (ps pageSuppressorImpl) SuppressEntry(entry ListEntry) (*Handle, error) {
+	log.Println("retrieving revisions for page:", entry.Title)
+	revs, err := ps.revRepo.GetAllByPageName(entry.Title)
 	if err != nil {
 		log.Println("failed to retrieve revisions for page:", err)
+		return nil, err
+	}
+
+	covered := make([]mediawiki.Revision, 0, len(revs))
+	for _, rev := range revs {
+		if entry.Covers(rev) {
+			covered = append(covered, rev)
+		}
+	}
+
+	return ps.revSuppressor.SuppressRevisions(covered), nil
+}
+
+func (ps pageSuppressorImpl) SuppressEntries(entries []ListEntry) error {
+	names := EntryTitles(entries)
+
+	pages, err := ps.revRepo.GetPagesByNames(names)
+	if err != nil {
+		log.Println("failed to resolve pages:", err)
 		return err
 	}
 
-	err = ps.revSuppressor.SuppressRevisions(revs)
+	failed := 0
+	handles := make(map[string]*Handle, len(pages))
+
+	for _, entry := range entries {
+		if entry.Title == "" {
+			// Patterns and users cannot be enumerated, they apply to recent changes only
+			continue
+		}
+
+		page, ok := pages[entry.Title]
+		if !ok || page.IsMissing || page.IsInvalid {
+			log.Printf("page [%s] does not exist, skipping", entry.Title)
+			continue
+		}
+
+		// The title may have been normalized or followed through a redirect
+		entry.Title = page.Title
+
+		handle, err := ps.SuppressEntry(entry)
+		if err != nil {
+			log.Printf("failed to suppress [%s] revisions: %v", page.Title, err)
+			failed++
+			continue
+		}
+
+		handles[page.Title] = handle
+	}
+
+	// Pages are submitted first and awaited afterwards, so that batches fill up
+	for title, handle := range handles {
+		err = handle.Err()
+		if err != nil {
+			log.Printf("failed to suppress [%s] revisions: %v", title, err)
+			failed++
+		}
+	}
+
+	if failed > 0 {
+		return fmt.Errorf("failed to suppress %d of %d pages", failed, len(names))
+	}
 
-	return err
+	return nil
 }
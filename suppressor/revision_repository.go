@@ -3,26 +3,45 @@ package suppressor
 import (
 	"freedom-sentry/mediawiki"
 	"freedom-sentry/mediawiki/action/query"
+	"freedom-sentry/util"
 	"time"
 )
 
+// maxTitlesPerRequest is the limit of titles in one query for clients without apihighlimits
+const maxTitlesPerRequest = 50
+
 type RevisionRepository interface {
 	GetAllByPageName(name string) ([]mediawiki.Revision, error)
+	// GetPagesByNames resolves the given titles in batches, keying pages by the requested name.
+	// Only the latest revision of each page is returned.
+	GetPagesByNames(names []string) (map[string]mediawiki.Page, error)
+	// NormalizeTitles returns the titles as the wiki writes them, keyed by the requested name. Redirects are not
+	// followed and invalid titles are left out.
+	NormalizeTitles(names []string) (map[string]string, error)
 	GetLatestPageContent(name string) (string, error)
+	// GetLatestRevision returns the latest revision of the page with its content, or an empty one if the page
+	// does not exist.
+	GetLatestRevision(name string) (mediawiki.Revision, error)
 	GetRecentChanges(since time.Time) ([]mediawiki.Revision, error)
 }
 
-func NewRepository(api mediawiki.Api) RevisionRepository {
-	return &revRepoImpl{api: api}
+func NewRepository(api mediawiki.Api, opts ...util.Option[revRepoImpl]) RevisionRepository {
+	rr := &revRepoImpl{api: api}
+
+	util.ApplyOptions(rr, opts...)
+
+	return rr
 }
 
 type revRepoImpl struct {
 	api mediawiki.Api
+
+	includesBotChanges bool
 }
 
 func (rr *revRepoImpl) GetAllByPageName(name string) ([]mediawiki.Revision, error) {
 	revProp := &query.RevisionsQueryProperty{
-		Properties: []string{"ids", "timestamp", "user"},
+		Properties: []string{"ids", "timestamp", "user", "userid", "comment", "sha1", "size", "tags"},
 		Limit:      5000,
 	}
 
@@ -32,14 +51,84 @@ func (rr *revRepoImpl) GetAllByPageName(name string) ([]mediawiki.Revision, erro
 		FollowRedirects: true,
 	}
 
-	err := rr.api.Execute(q)
+	var revs []mediawiki.Revision
+
+	err := query.ExecuteAll(rr.api, q, func() error {
+		revs = append(revs, revProp.GetRevisions()...)
+		return nil
+	})
+
+	return revs, err
+}
+
+func (rr *revRepoImpl) GetPagesByNames(names []string) (map[string]mediawiki.Page, error) {
+	pages := make(map[string]mediawiki.Page, len(names))
+
+	err := rr.queryPagesInBatches(names, true, func(batch []string, revProp *query.RevisionsQueryProperty) {
+		for _, name := range batch {
+			if page, ok := revProp.GetPage(name); ok {
+				pages[name] = page
+			}
+		}
+	})
+
+	return pages, err
+}
+
+func (rr *revRepoImpl) NormalizeTitles(names []string) (map[string]string, error) {
+	normalized := make(map[string]string, len(names))
+
+	err := rr.queryPagesInBatches(names, false, func(batch []string, revProp *query.RevisionsQueryProperty) {
+		for _, name := range batch {
+			if page, ok := revProp.GetPage(name); ok && !page.IsInvalid {
+				normalized[name] = page.Title
+			}
+		}
+	})
+
+	return normalized, err
+}
+
+// queryPagesInBatches queries the latest revisions of the pages, as many titles per request as the API accepts.
+func (rr *revRepoImpl) queryPagesInBatches(names []string, followsRedirects bool, fn func(batch []string, revProp *query.RevisionsQueryProperty)) error {
+	for start := 0; start < len(names); start += maxTitlesPerRequest {
+		end := start + maxTitlesPerRequest
+		if end > len(names) {
+			end = len(names)
+		}
+
+		batch := names[start:end]
+
+		revProp := &query.RevisionsQueryProperty{
+			Properties: []string{"ids", "timestamp"},
+		}
+
+		q := query.Query{
+			Properties:      []query.Property{revProp},
+			PageNames:       batch,
+			FollowRedirects: followsRedirects,
+		}
+
+		err := rr.api.Execute(q)
+		if err != nil {
+			return err
+		}
+
+		fn(batch, revProp)
+	}
 
-	return revProp.GetRevisions(), err
+	return nil
 }
 
 func (rr *revRepoImpl) GetLatestPageContent(name string) (string, error) {
+	rev, err := rr.GetLatestRevision(name)
+
+	return rev.Content, err
+}
+
+func (rr *revRepoImpl) GetLatestRevision(name string) (mediawiki.Revision, error) {
 	revProp := &query.RevisionsQueryProperty{
-		Properties: []string{"ids", "content"},
+		Properties: []string{"ids", "timestamp", "contentmodel", "content"},
 		Limit:      1,
 	}
 
@@ -51,31 +140,42 @@ func (rr *revRepoImpl) GetLatestPageContent(name string) (string, error) {
 
 	err := rr.api.Execute(q)
 	if err != nil {
-		return "", err
+		return mediawiki.Revision{}, err
 	}
 
 	revisions := revProp.GetRevisions()
 	if len(revisions) == 0 {
-		return "", nil
+		return mediawiki.Revision{}, nil
 	}
 
-	return revisions[0].Content, nil
+	return revisions[0], nil
 }
 
+// GetRecentChanges returns edits, page creations and log entries since the given time. Log entries have
+// no revision id but a Log event instead.
 func (rr *revRepoImpl) GetRecentChanges(since time.Time) ([]mediawiki.Revision, error) {
 	changes := query.RecentChangesQueryList{
 		Start:      since,
 		Direction:  "newer",
-		Properties: []string{"title", "timestamp", "ids", "user"},
-		Show:       []string{"!bot"},
+		Properties: []string{"title", "timestamp", "ids", "user", "userid", "comment", "sha1", "sizes", "tags", "loginfo"},
 		Limit:      5000,
-		Types:      []string{"edit"},
-		TopOnly:    true,
+		// Not only the latest revisions: edits overwritten within the same poll must be seen too
+		Types: []string{"edit", "new", "log"},
 	}
+
+	if !rr.includesBotChanges {
+		changes.Show = []string{"!bot"}
+	}
+
 	action := query.Query{
 		List: []query.List{&changes},
 	}
-	err := rr.api.Execute(action)
+	var revs []mediawiki.Revision
+
+	err := query.ExecuteAll(rr.api, action, func() error {
+		revs = append(revs, changes.GetRecentChanges()...)
+		return nil
+	})
 
-	return changes.GetRecentChanges(), err
+	return revs, err
 }
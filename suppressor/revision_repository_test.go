@@ -1,6 +1,8 @@
 package suppressor
 
 import (
+	"encoding/json"
+	"fmt"
 	"freedom-sentry/mediawiki"
 	"freedom-sentry/util"
 	"reflect"
@@ -13,6 +15,7 @@ func Test_revRepoImpl_GetRecentChanges(t *testing.T) {
 
 	tests := []struct {
 		name            string
+		includesBots    bool
 		expectedPayload map[string]interface{}
 		apiReturn       mediawiki.Action
 		wantApiErr      bool
@@ -34,9 +37,25 @@ func Test_revRepoImpl_GetRecentChanges(t *testing.T) {
 				"rcdir":     "newer",
 				"rcshow":    []string{"!bot"},
 				"rclimit":   5000,
-				"rcprop":    []string{"title", "timestamp", "ids", "user"},
-				"rctype":    []string{"edit"},
-				"rctoponly": true,
+				"rcprop":    []string{"title", "timestamp", "ids", "user", "userid", "comment", "sha1", "sizes", "tags", "loginfo"},
+				"rctype":    []string{"edit", "new", "log"},
+				"rctoponly": false,
+			},
+			want: util.CreateNilSlice[mediawiki.Revision](),
+		},
+		{
+			name:         "Will include bot changes",
+			includesBots: true,
+			expectedPayload: map[string]interface{}{
+				"action":    "query",
+				"list":      "recentchanges",
+				"rcstart":   "2022-04-20T12:13:14Z",
+				"rcdir":     "newer",
+				"rcshow":    []string(nil),
+				"rclimit":   5000,
+				"rcprop":    []string{"title", "timestamp", "ids", "user", "userid", "comment", "sha1", "sizes", "tags", "loginfo"},
+				"rctype":    []string{"edit", "new", "log"},
+				"rctoponly": false,
 			},
 			want: util.CreateNilSlice[mediawiki.Revision](),
 		},
@@ -47,9 +66,7 @@ func Test_revRepoImpl_GetRecentChanges(t *testing.T) {
 			api := &mockApi{
 				executeThrowError: tt.wantApiErr,
 			}
-			rr := &revRepoImpl{
-				api: api,
-			}
+			rr := NewRepository(api, WithBotChanges(tt.includesBots))
 
 			got, err := rr.GetRecentChanges(expectedTime)
 			if (err != nil) != tt.wantErr {
@@ -77,3 +94,107 @@ func Test_revRepoImpl_GetRecentChanges(t *testing.T) {
 		})
 	}
 }
+
+func Test_revRepoImpl_GetPagesByNames(t *testing.T) {
+	tests := []struct {
+		name           string
+		titles         int
+		wantCalls      int
+		wantLastTitles int
+		wantApiErr     bool
+		wantErr        bool
+	}{
+		{
+			name:      "No titles",
+			titles:    0,
+			wantCalls: 0,
+		},
+		{
+			name:           "One batch",
+			titles:         50,
+			wantCalls:      1,
+			wantLastTitles: 50,
+		},
+		{
+			name:           "Three batches",
+			titles:         120,
+			wantCalls:      3,
+			wantLastTitles: 20,
+		},
+		{
+			name:       "Stops on API error",
+			titles:     120,
+			wantCalls:  1,
+			wantApiErr: true,
+			wantErr:    true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			api := &mockApi{
+				executeThrowError: tt.wantApiErr,
+			}
+			rr := &revRepoImpl{
+				api: api,
+			}
+
+			titles := make([]string, tt.titles)
+			for i := range titles {
+				titles[i] = fmt.Sprintf("Page %d", i)
+			}
+
+			_, err := rr.GetPagesByNames(titles)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("GetPagesByNames() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+
+			if api.executeCount != tt.wantCalls {
+				t.Errorf("GetPagesByNames() called API %d times, want %d", api.executeCount, tt.wantCalls)
+				return
+			}
+
+			if tt.wantErr || tt.wantCalls == 0 {
+				return
+			}
+
+			payload := api.executeAction.ToActionPayload()
+			if got := len(payload["titles"].([]string)); got != tt.wantLastTitles {
+				t.Errorf("GetPagesByNames() last batch has %d titles, want %d", got, tt.wantLastTitles)
+			}
+
+			if _, ok := payload["rvlimit"]; ok {
+				t.Errorf("GetPagesByNames() must not limit revisions of multiple pages")
+			}
+		})
+	}
+}
+
+func Test_revRepoImpl_NormalizeTitles(t *testing.T) {
+	var response map[string]interface{}
+	_ = json.Unmarshal([]byte(`{"query": {
+		"normalized": [{"from": "main_page", "to": "Main page"}, {"from": "talk:x", "to": "Talk:X"}],
+		"pages": {
+			"1": {"pageid": 1, "ns": 0, "title": "Main page", "revisions": [{"revid": 42, "timestamp": "2022-04-20T12:13:14Z"}]},
+			"-1": {"ns": 1, "title": "Talk:X", "missing": ""},
+			"-2": {"title": "A[b]", "invalidreason": "The requested page title contains invalid characters: \"[\".", "invalid": ""}
+		}
+	}}`), &response)
+
+	api := &mockApi{executeResponse: response}
+	rr := &revRepoImpl{api: api}
+
+	got, err := rr.NormalizeTitles([]string{"main_page", "talk:x", "A[b]"})
+	if err != nil {
+		t.Fatalf("NormalizeTitles() error = %v", err)
+	}
+
+	want := map[string]string{"main_page": "Main page", "talk:x": "Talk:X"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("NormalizeTitles() = %v, want %v", got, want)
+	}
+
+	if _, ok := api.executeAction.ToActionPayload()["redirects"]; ok {
+		t.Errorf("NormalizeTitles() must not follow redirects")
+	}
+}
@@ -1,54 +1,140 @@
 package suppressor
 
 import (
+	"fmt"
 	"freedom-sentry/mediawiki"
 	"freedom-sentry/mediawiki/action/revisiondelete"
+	"golang.org/x/exp/slices"
 	"log"
 	"time"
 )
 
+const (
+	// DefaultBatchSize is the limit of revisions in one revisiondelete request
+	DefaultBatchSize = 50
+	// HighLimitsBatchSize is the limit for users with the apihighlimits right
+	HighLimitsBatchSize = 500
+)
+
+// suppressionHideDetails are the details hidden from everyone, including administrators
+var suppressionHideDetails = []string{revisiondelete.HideUser, revisiondelete.HideComment}
+
+// HideProfileFn returns the details to hide in revisions of the page with the given title.
+type HideProfileFn func(title string) mediawiki.Visibility
+
+// of falls back to the default profile if no function is set.
+func (fn HideProfileFn) of(title string) mediawiki.Visibility {
+	if fn == nil {
+		return DefaultHideProfile
+	}
+
+	return fn(title)
+}
+
 type RevisionSuppressor interface {
-	SuppressRevisions(revs []mediawiki.Revision) error
+	// SuppressRevisions submits revisions for suppression. The returned handle completes once every
+	// revision has an outcome, which may happen after the call returns.
+	SuppressRevisions(revs []mediawiki.Revision) *Handle
 }
 
+// revisionSuppressorImpl suppresses revisions of a single page synchronously.
 type revisionSuppressorImpl struct {
-	api mediawiki.Api
+	api         mediawiki.Api
+	hideProfile HideProfileFn
 }
 
-func (rs revisionSuppressorImpl) SuppressRevisions(revs []mediawiki.Revision) error {
+func (rs revisionSuppressorImpl) SuppressRevisions(revs []mediawiki.Revision) *Handle {
 	if len(revs) == 0 {
 		log.Println("nothing to suppress")
-		return nil
+		return completedHandle(nil)
 	}
 
+	target := revs[0].Title
 	ids := make([]mediawiki.RevisionId, 0, len(revs))
 
 	for _, rev := range revs {
+		if rev.Title != target {
+			err := fmt.Errorf("revisions of [%s] and [%s] cannot be suppressed at once", target, rev.Title)
+			return completedHandle(outcomesWithErr(revs, err))
+		}
+
 		ids = append(ids, rev.Id)
 	}
 
-	log.Printf("suppressing %d revisions", len(ids))
+	log.Printf("suppressing %d revisions of [%s]", len(ids), target)
+
+	action := getActionForRevisions(target, ids, revisiondelete.HideDetailsOf(rs.hideProfile.of(target)))
+
+	err := rs.api.Execute(&action)
+	if err != nil {
+		return completedHandle(outcomesWithErr(revs, err))
+	}
+
+	result := action.GetResult()
+
+	log.Printf("suppressed %d revisions of [%s], status: %s", len(result.Items), target, result.Status)
+
+	return completedHandle(outcomesForResult(revs, result))
+}
+
+func outcomesForResult(revs []mediawiki.Revision, result revisiondelete.Result) []Outcome {
+	items := make(map[mediawiki.RevisionId]revisiondelete.ResultItem, len(result.Items))
+	for _, item := range result.Items {
+		items[item.Id] = item
+	}
+
+	outcomes := make([]Outcome, len(revs))
 
-	return rs.api.Execute(getActionForRevisions(ids))
+	for i, rev := range revs {
+		outcomes[i] = Outcome{Revision: rev}
+
+		item, ok := items[rev.Id]
+		if !ok {
+			outcomes[i].Err = &RevisionError{Id: rev.Id, Status: "unknown"}
+			continue
+		}
+
+		if !item.IsSuccess() {
+			outcomes[i].Err = &RevisionError{Id: rev.Id, Status: item.Status, Errors: item.Errors}
+			continue
+		}
+
+		outcomes[i].Revision.Visibility = item.Visibility
+	}
+
+	return outcomes
 }
 
-func getActionForRevisions(revs []mediawiki.RevisionId) revisiondelete.RevisionDelete {
+func getActionForRevisions(target string, revs []mediawiki.RevisionId, hideDetails []string) revisiondelete.RevisionDelete {
 	return revisiondelete.RevisionDelete{
 		Type:        "revision",
+		Target:      target,
 		Revisions:   revs,
-		HideDetails: []string{"user", "comment"},
+		HideDetails: hideDetails,
 		Suppress:    mediawiki.TextBoolYes,
 	}
 }
 
-func NewRevisionSuppressor(api mediawiki.Api) RevisionSuppressor {
+// BatchSizeForRights returns how many revisions the user with the given rights can suppress in one request.
+func BatchSizeForRights(rights []string) int {
+	if slices.Contains(rights, "apihighlimits") {
+		return HighLimitsBatchSize
+	}
+
+	return DefaultBatchSize
+}
+
+func NewRevisionSuppressor(api mediawiki.Api, batchSize int, deadLetters DeadLetterSink, hideProfile HideProfileFn) RevisionSuppressor {
 	return &filteringRevisionSuppressor{
+		hideProfile: hideProfile,
 		suppressor: &batchingSuppressor{
 			period: 5 * time.Second,
-			size:   500,
+			size:   batchSize,
 			suppressor: &revisionSuppressorImpl{
-				api: api,
+				api:         api,
+				hideProfile: hideProfile,
 			},
+			deadLetters: deadLetters,
 		},
 		/*suppressor: &revisionSuppressorImpl{
 			api: api,
@@ -56,22 +142,40 @@ func NewRevisionSuppressor(api mediawiki.Api) RevisionSuppressor {
 	}
 }
 
+// filteringRevisionSuppressor skips revisions that already have every detail hidden the way suppression would.
 type filteringRevisionSuppressor struct {
-	suppressor RevisionSuppressor
+	hideProfile HideProfileFn
+	suppressor  RevisionSuppressor
 }
 
-// TODO: If an article is not found or moved, message back to the list manager to update the source list in the wiki
-
-func (rs filteringRevisionSuppressor) SuppressRevisions(revs []mediawiki.Revision) error {
+func (rs filteringRevisionSuppressor) SuppressRevisions(revs []mediawiki.Revision) *Handle {
 	filtered := make([]mediawiki.Revision, 0, len(revs))
+	var skipped []Outcome
+
+	profiles := make(map[string]mediawiki.Visibility)
 
 	for _, rev := range revs {
-		if rev.IsSuppressed {
+		profile, ok := profiles[rev.Title]
+		if !ok {
+			profile = rs.hideProfile.of(rev.Title) | mediawiki.VisibilitySuppressed
+			profiles[rev.Title] = profile
+		}
+
+		if rev.Visibility.Has(profile) {
+			skipped = append(skipped, Outcome{Revision: rev})
 			continue
 		}
 
 		filtered = append(filtered, rev)
 	}
 
-	return rs.suppressor.SuppressRevisions(filtered)
+	if len(skipped) == 0 {
+		return rs.suppressor.SuppressRevisions(filtered)
+	}
+
+	h := newHandle(len(revs))
+	h.resolve(skipped...)
+	h.forward(rs.suppressor.SuppressRevisions(filtered))
+
+	return h
 }
@@ -3,6 +3,7 @@ package suppressor
 import (
 	"errors"
 	"freedom-sentry/mediawiki"
+	"freedom-sentry/mediawiki/action/revisiondelete"
 	"reflect"
 	"strings"
 	"testing"
@@ -10,18 +11,25 @@ import (
 
 type mockApi struct {
 	executeCalled     bool
+	executeCount      int
 	executeAction     mediawiki.Action
 	executeThrowError bool
+	executeResponse   map[string]interface{}
 }
 
 func (m *mockApi) Execute(action mediawiki.Action) error {
 	m.executeCalled = true
+	m.executeCount++
 	m.executeAction = action
 
 	if m.executeThrowError {
 		return errors.New("dummy error")
 	}
 
+	if m.executeResponse != nil {
+		return action.SetResponse(m.executeResponse)
+	}
+
 	return nil
 }
 
@@ -33,16 +41,16 @@ type mockSuppressor struct {
 	callHistory string
 }
 
-func (m *mockSuppressor) SuppressRevisions(revs []mediawiki.Revision) error {
+func (m *mockSuppressor) SuppressRevisions(revs []mediawiki.Revision) *Handle {
 	m.called = true
 	m.revs = revs
 	m.addHistory(revs)
 
 	if m.throwError {
-		return errors.New("dummy error")
+		return completedHandle(outcomesWithErr(revs, errors.New("dummy error")))
 	}
 
-	return nil
+	return completedHandle(outcomesWithErr(revs, nil))
 }
 
 func (m *mockSuppressor) addHistory(revs []mediawiki.Revision) {
@@ -60,6 +68,17 @@ func (m *mockSuppressor) addHistory(revs []mediawiki.Revision) {
 	m.callHistory += strings.Join(batch, ",")
 }
 
+const suppressedVisibility = mediawiki.VisibilityCommentHidden | mediawiki.VisibilityUserHidden | mediawiki.VisibilitySuppressed
+
+// contentHidingProfile hides the content of the page titled Content as well
+func contentHidingProfile(title string) mediawiki.Visibility {
+	if title == "Content" {
+		return DefaultHideProfile | mediawiki.VisibilityTextHidden
+	}
+
+	return DefaultHideProfile
+}
+
 func Test_filteringRevisionSuppressor_SuppressRevisions(t *testing.T) {
 	tests := []struct {
 		name     string
@@ -82,8 +101,8 @@ func Test_filteringRevisionSuppressor_SuppressRevisions(t *testing.T) {
 		{
 			name: "Nothing to suppress",
 			revs: []mediawiki.Revision{
-				{Id: "1", IsSuppressed: true},
-				{Id: "2", IsSuppressed: true},
+				{Id: "1", Visibility: suppressedVisibility},
+				{Id: "2", Visibility: suppressedVisibility},
 			},
 			expected: []mediawiki.Revision{},
 			wantErr:  false,
@@ -91,35 +110,62 @@ func Test_filteringRevisionSuppressor_SuppressRevisions(t *testing.T) {
 		{
 			name: "Some to suppress",
 			revs: []mediawiki.Revision{
-				{Id: "1", IsSuppressed: false},
-				{Id: "2", IsSuppressed: true},
+				{Id: "1"},
+				{Id: "2", Visibility: suppressedVisibility},
+			},
+			expected: []mediawiki.Revision{
+				{Id: "1"},
+			},
+			wantErr: false,
+		},
+		{
+			name: "Partially hidden or deleted without suppression are upgraded",
+			revs: []mediawiki.Revision{
+				{Id: "1", Visibility: mediawiki.VisibilityUserHidden | mediawiki.VisibilitySuppressed},
+				{Id: "2", Visibility: mediawiki.VisibilityCommentHidden | mediawiki.VisibilityUserHidden},
+				{Id: "3", Visibility: mediawiki.VisibilitySuppressed},
+				{Id: "4", Visibility: suppressedVisibility | mediawiki.VisibilityTextHidden},
+			},
+			expected: []mediawiki.Revision{
+				{Id: "1", Visibility: mediawiki.VisibilityUserHidden | mediawiki.VisibilitySuppressed},
+				{Id: "2", Visibility: mediawiki.VisibilityCommentHidden | mediawiki.VisibilityUserHidden},
+				{Id: "3", Visibility: mediawiki.VisibilitySuppressed},
+			},
+			wantErr: false,
+		},
+		{
+			name: "Hide profile of the page",
+			revs: []mediawiki.Revision{
+				{Id: "1", Title: "Content", Visibility: suppressedVisibility},
+				{Id: "2", Title: "Content", Visibility: suppressedVisibility | mediawiki.VisibilityTextHidden},
+				{Id: "3", Title: "Other", Visibility: suppressedVisibility},
 			},
 			expected: []mediawiki.Revision{
-				{Id: "1", IsSuppressed: false},
+				{Id: "1", Title: "Content", Visibility: suppressedVisibility},
 			},
 			wantErr: false,
 		},
 		{
 			name: "All are suppressed",
 			revs: []mediawiki.Revision{
-				{Id: "1", IsSuppressed: false},
-				{Id: "2", IsSuppressed: false},
+				{Id: "1"},
+				{Id: "2"},
 			},
 			expected: []mediawiki.Revision{
-				{Id: "1", IsSuppressed: false},
-				{Id: "2", IsSuppressed: false},
+				{Id: "1"},
+				{Id: "2"},
 			},
 			wantErr: false,
 		},
 		{
 			name: "Error from fn",
 			revs: []mediawiki.Revision{
-				{Id: "1", IsSuppressed: false},
-				{Id: "2", IsSuppressed: false},
+				{Id: "1"},
+				{Id: "2"},
 			},
 			expected: []mediawiki.Revision{
-				{Id: "1", IsSuppressed: false},
-				{Id: "2", IsSuppressed: false},
+				{Id: "1"},
+				{Id: "2"},
 			},
 			wantErr: true,
 		},
@@ -131,8 +177,9 @@ func Test_filteringRevisionSuppressor_SuppressRevisions(t *testing.T) {
 				throwError: tt.wantErr,
 			}
 
-			rs := filteringRevisionSuppressor{suppressor: suppressor}
-			err := rs.SuppressRevisions(tt.revs)
+			rs := filteringRevisionSuppressor{hideProfile: contentHidingProfile, suppressor: suppressor}
+			handle := rs.SuppressRevisions(tt.revs)
+			err := handle.Err()
 			if (err != nil) != tt.wantErr {
 				t.Errorf("SuppressRevisions() error = %v, wantErr %v", err, tt.wantErr)
 				return
@@ -141,6 +188,137 @@ func Test_filteringRevisionSuppressor_SuppressRevisions(t *testing.T) {
 			if !reflect.DeepEqual(tt.expected, suppressor.revs) {
 				t.Errorf("SuppressRevisions(), bad action: got = %v, want %v", suppressor.revs, tt.expected)
 			}
+
+			if len(handle.Wait()) != len(tt.revs) {
+				t.Errorf("SuppressRevisions() resolved %d outcomes, want one for each of %d revisions", len(handle.Wait()), len(tt.revs))
+			}
+		})
+	}
+}
+
+func Test_revisionSuppressorImpl_SuppressRevisions(t *testing.T) {
+	tests := []struct {
+		name       string
+		revs       []mediawiki.Revision
+		response   map[string]interface{}
+		wantApiErr bool
+		wantFailed []mediawiki.RevisionId
+		wantErr    bool
+	}{
+		{
+			name: "Nothing to suppress",
+		},
+		{
+			name:    "Revisions of different pages",
+			revs:    []mediawiki.Revision{{Id: "1", Title: "A"}, {Id: "2", Title: "B"}},
+			wantErr: true,
+		},
+		{
+			name:       "API error",
+			revs:       []mediawiki.Revision{{Id: "1", Title: "A"}},
+			wantApiErr: true,
+			wantErr:    true,
+		},
+		{
+			name: "All suppressed",
+			revs: []mediawiki.Revision{{Id: "1", Title: "A"}, {Id: "2", Title: "A"}},
+			response: map[string]interface{}{
+				"revisiondelete": map[string]interface{}{
+					"status": "Success",
+					"items": []interface{}{
+						map[string]interface{}{"status": "success", "id": float64(1)},
+						map[string]interface{}{"status": "success", "id": float64(2)},
+					},
+				},
+			},
+		},
+		{
+			name: "Hide profile of the page",
+			revs: []mediawiki.Revision{{Id: "1", Title: "Content"}},
+			response: map[string]interface{}{
+				"revisiondelete": map[string]interface{}{
+					"status": "Success",
+					"items": []interface{}{
+						map[string]interface{}{"status": "success", "id": float64(1)},
+					},
+				},
+			},
+		},
+		{
+			name: "Partial failure",
+			revs: []mediawiki.Revision{{Id: "1", Title: "A"}, {Id: "2", Title: "A"}},
+			response: map[string]interface{}{
+				"revisiondelete": map[string]interface{}{
+					"status": "Success",
+					"items": []interface{}{
+						map[string]interface{}{"status": "success", "id": float64(1)},
+						map[string]interface{}{
+							"status": "fail",
+							"id":     float64(2),
+							"errors": []interface{}{
+								map[string]interface{}{"type": "error", "message": "revdelete-modify-missing"},
+							},
+						},
+					},
+				},
+			},
+			wantFailed: []mediawiki.RevisionId{"2"},
+			wantErr:    true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			api := &mockApi{
+				executeThrowError: tt.wantApiErr,
+				executeResponse:   tt.response,
+			}
+			rs := revisionSuppressorImpl{api: api, hideProfile: contentHidingProfile}
+
+			err := rs.SuppressRevisions(tt.revs).Err()
+			if (err != nil) != tt.wantErr {
+				t.Errorf("SuppressRevisions() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+
+			if api.executeCalled && len(tt.revs) > 0 && api.executeAction.ToActionPayload()["target"] != tt.revs[0].Title {
+				t.Errorf("SuppressRevisions() target = %v, want %v", api.executeAction.ToActionPayload()["target"], tt.revs[0].Title)
+			}
+
+			if api.executeCalled && len(tt.revs) > 0 {
+				wantHide := revisiondelete.HideDetailsOf(contentHidingProfile(tt.revs[0].Title))
+				if got := api.executeAction.ToActionPayload()["hide"]; !reflect.DeepEqual(got, wantHide) {
+					t.Errorf("SuppressRevisions() hide = %v, want %v", got, wantHide)
+				}
+			}
+
+			if tt.wantFailed == nil {
+				return
+			}
+
+			var partialErr *PartialFailureError
+			if !errors.As(err, &partialErr) {
+				t.Fatalf("SuppressRevisions() error = %v, want *PartialFailureError", err)
+			}
+
+			failed := make([]mediawiki.RevisionId, len(partialErr.Failed))
+			for i, outcome := range partialErr.Failed {
+				failed[i] = outcome.Revision.Id
+			}
+
+			if !reflect.DeepEqual(failed, tt.wantFailed) {
+				t.Errorf("SuppressRevisions() failed = %v, want %v", failed, tt.wantFailed)
+			}
 		})
 	}
 }
+
+func TestBatchSizeForRights(t *testing.T) {
+	if got := BatchSizeForRights([]string{"suppressrevision"}); got != DefaultBatchSize {
+		t.Errorf("BatchSizeForRights() = %d, want %d", got, DefaultBatchSize)
+	}
+
+	if got := BatchSizeForRights([]string{"suppressrevision", "apihighlimits"}); got != HighLimitsBatchSize {
+		t.Errorf("BatchSizeForRights() = %d, want %d", got, HighLimitsBatchSize)
+	}
+}
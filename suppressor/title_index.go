@@ -0,0 +1,88 @@
+package suppressor
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"freedom-sentry/util"
+)
+
+// A public list reveals which pages are suppressed. Entries may give a salted hash of the title instead:
+//
+//	sha256:<hex of SHA-256(salt + normalized title)>
+//
+// The salt is kept off the wiki, so that the hashes cannot be checked against a dump of all titles. Hashes are
+// produced by the "hash" command.
+//
+// Recent changes are matched by hashing their titles. The full scan needs titles to read the histories, which
+// the list does not have, so they are resolved through a local plaintext index kept next to the other state
+// files and never published:
+//
+//   - the "hash" command adds the titles it hashes to the index, so the operator listing a page fills it in.
+//     The titles are normalized through the API first, as only the wiki knows how it capitalizes titles and
+//     names its namespaces;
+//   - a title of a recent change matching a hashed entry is added too, so pages listed elsewhere are learned
+//     from their first edit.
+//
+// Hashed entries without an indexed title are skipped by the full scan and reported until they are resolved.
+
+// titleHashPrefix marks a hashed title in the list
+const titleHashPrefix = "sha256:"
+
+// TitleIndex maps hashes of titles to the titles, in a JSON file readable by the operator only. The index is
+// kept in memory only if the path is empty.
+type TitleIndex struct {
+	store *util.JsonFileStore[string, indexedTitle]
+	salt  string
+}
+
+type indexedTitle struct {
+	Hash  string `json:"hash"`
+	Title string `json:"title"`
+}
+
+func NewTitleIndex(path, salt string) *TitleIndex {
+	return &TitleIndex{
+		store: util.NewJsonFileStore(path, func(title indexedTitle) string {
+			return title.Hash
+		}),
+		salt: salt,
+	}
+}
+
+// IsEnabled reports whether titles can be hashed, unsalted hashes are never produced nor matched.
+func (i *TitleIndex) IsEnabled() bool {
+	return i.salt != ""
+}
+
+// Hash returns the hash of the title as it appears in the list, without the prefix. The title is expected as
+// the wiki writes it, only underscores are replaced and spaces trimmed.
+func (i *TitleIndex) Hash(title string) string {
+	hash := sha256.Sum256([]byte(i.salt + normalizeHashedTitle(title)))
+
+	return hex.EncodeToString(hash[:])
+}
+
+// Add hashes the title and keeps its normalized form in the index, returning the hash.
+func (i *TitleIndex) Add(title string) (string, error) {
+	hash := i.Hash(title)
+
+	return hash, i.store.Update(func(titles map[string]indexedTitle) {
+		titles[hash] = indexedTitle{Hash: hash, Title: normalizeHashedTitle(title)}
+	})
+}
+
+func normalizeHashedTitle(title string) string {
+	normalized, err := normalizeListTitle(title)
+	if err != nil {
+		return title
+	}
+
+	return normalized
+}
+
+// Resolve returns the title of the hash, if it has been indexed.
+func (i *TitleIndex) Resolve(hash string) (string, bool, error) {
+	title, ok, err := i.store.Get(hash)
+
+	return title.Title, ok, err
+}
@@ -0,0 +1,51 @@
+package suppressor
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+func TestTitleIndex_Hash(t *testing.T) {
+	index := NewTitleIndex("", "salt")
+
+	// echo -n "saltMain Page" | sha256sum
+	want := "b128088f4ec8007629a2c1ef6375f30230aa22d3d5489231476f2d2fd56a27d2"
+
+	for _, title := range []string{"Main Page", "Main_Page", " Main Page "} {
+		if got := index.Hash(title); got != want {
+			t.Errorf("Hash(%q) = %v, want %v", title, got, want)
+		}
+	}
+
+	if NewTitleIndex("", "pepper").Hash("Main Page") == want {
+		t.Errorf("Hash() must depend on the salt")
+	}
+}
+
+func TestTitleIndex_Resolve(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "title_index.json")
+
+	hash, err := NewTitleIndex(path, "salt").Add("Main_Page")
+	if err != nil {
+		t.Fatalf("Add() error = %v", err)
+	}
+
+	index := NewTitleIndex(path, "salt")
+
+	if title, ok, err := index.Resolve(hash); err != nil || !ok || title != "Main Page" {
+		t.Errorf("Resolve() = %v, %v, %v, want the normalized title read from the file", title, ok, err)
+	}
+
+	if _, ok, _ := index.Resolve(index.Hash("Talk:Main Page")); ok {
+		t.Errorf("Resolve() must not resolve titles which have not been indexed")
+	}
+
+	talkHash, err := NewTitleIndex(path, "salt").Add("Talk:Main Page")
+	if err != nil {
+		t.Fatalf("Add() error = %v", err)
+	}
+
+	if title, ok, err := index.Resolve(talkHash); err != nil || !ok || title != "Talk:Main Page" {
+		t.Errorf("Resolve() = %v, %v, %v, want the title added to the file by another instance", title, ok, err)
+	}
+}
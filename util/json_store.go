@@ -0,0 +1,158 @@
+package util
+
+import (
+	"encoding/json"
+	"errors"
+	"golang.org/x/exp/constraints"
+	"golang.org/x/exp/maps"
+	"golang.org/x/exp/slices"
+	"io/fs"
+	"os"
+	"sync"
+)
+
+// JsonFileStore keeps records in a JSON file, as a list ordered by their keys.
+//
+// Every update reads the file and writes it back, which lets the command line tools change the state of a running
+// instance. Reads keep the records until the file changes. The records are kept in memory only if the path is empty.
+type JsonFileStore[K constraints.Ordered, V comparable] struct {
+	path  string
+	keyOf func(record V) K
+
+	lock    sync.Mutex
+	records map[K]V
+	loaded  fs.FileInfo // Of the file the records were read from, nil if they are to be read again
+}
+
+func NewJsonFileStore[K constraints.Ordered, V comparable](path string, keyOf func(record V) K) *JsonFileStore[K, V] {
+	return &JsonFileStore[K, V]{
+		path:    path,
+		keyOf:   keyOf,
+		records: make(map[K]V),
+	}
+}
+
+// Update passes the records by their keys to fn, and saves them if fn changed them.
+func (s *JsonFileStore[K, V]) Update(fn func(records map[K]V)) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	if s.path == "" {
+		fn(s.records)
+		return nil
+	}
+
+	records, err := s.load()
+	if err != nil {
+		return err
+	}
+
+	before := maps.Clone(records)
+	fn(records)
+
+	if maps.Equal(before, records) {
+		return nil
+	}
+
+	s.loaded = nil
+
+	return s.save(records)
+}
+
+// Get returns the record of the key, if any.
+func (s *JsonFileStore[K, V]) Get(key K) (V, bool, error) {
+	var record V
+	var ok bool
+
+	err := s.View(func(records map[K]V) {
+		record, ok = records[key]
+	})
+
+	return record, ok, err
+}
+
+// View passes the records by their keys to fn, which must not change them. The file is read again only once it
+// has changed.
+func (s *JsonFileStore[K, V]) View(fn func(records map[K]V)) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	if s.path != "" {
+		err := s.refresh()
+		if err != nil {
+			return err
+		}
+	}
+
+	fn(s.records)
+
+	return nil
+}
+
+// refresh reads the records again if the file has changed since they were read.
+func (s *JsonFileStore[K, V]) refresh() error {
+	info, err := os.Stat(s.path)
+	if errors.Is(err, fs.ErrNotExist) {
+		s.records = make(map[K]V)
+		s.loaded = nil
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	if s.loaded != nil && info.ModTime().Equal(s.loaded.ModTime()) && info.Size() == s.loaded.Size() {
+		return nil
+	}
+
+	records, err := s.load()
+	if err != nil {
+		return err
+	}
+
+	s.records = records
+	s.loaded = info
+
+	return nil
+}
+
+func (s *JsonFileStore[K, V]) load() (map[K]V, error) {
+	records := make(map[K]V)
+
+	content, err := os.ReadFile(s.path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return records, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var list []V
+	err = json.Unmarshal(content, &list)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, record := range list {
+		records[s.keyOf(record)] = record
+	}
+
+	return records, nil
+}
+
+func (s *JsonFileStore[K, V]) save(records map[K]V) error {
+	keys := maps.Keys(records)
+	slices.Sort(keys)
+
+	list := make([]V, len(keys))
+	for i, key := range keys {
+		list[i] = records[key]
+	}
+
+	content, err := json.MarshalIndent(list, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	return WriteFileAtomically(s.path, content)
+}